@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNoTargets is returned by DoAcross and Targets.Do when there is no target to attempt.
+var ErrNoTargets = errors.New("retry: no targets")
+
+// Targets rotates attempts across endpoints, such as replicas, DNS addresses or
+// regions, and tracks the consecutive failures of each one. Every attempt goes to
+// the target with the fewest consecutive failures, taking turns between equals, so
+// a retry moves to the next target after a failure. A Targets can be shared by
+// concurrent calls to keep the failure tracking across them.
+type Targets[T any] struct {
+	mu       sync.Mutex
+	targets  []T
+	failures []int
+	next     int
+}
+
+// NewTargets creates Targets rotating across targets in order.
+func NewTargets[T any](targets ...T) *Targets[T] {
+	return &Targets[T]{
+		targets:  targets,
+		failures: make([]int, len(targets)),
+	}
+}
+
+// Do attempts f with retry logic, passing the target chosen for every attempt.
+func (t *Targets[T]) Do(ctx context.Context, f func(ctx context.Context, target T) error, opts *Option) error {
+	if len(t.targets) == 0 {
+		return ErrNoTargets
+	}
+	return Do(ctx, func() error {
+		i := t.pick()
+		err := f(ctx, t.targets[i])
+		t.record(i, err)
+		return err
+	}, opts)
+}
+
+// Failures returns the consecutive failures of each target, in the order of the targets.
+func (t *Targets[T]) Failures() []int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]int(nil), t.failures...)
+}
+
+// pick returns the index of the target with the fewest consecutive failures, starting
+// the search after the previously picked target.
+func (t *Targets[T]) pick() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	best := t.next % len(t.targets)
+	for n := 1; n < len(t.targets); n++ {
+		i := (t.next + n) % len(t.targets)
+		if t.failures[i] < t.failures[best] {
+			best = i
+		}
+	}
+	t.next = best + 1
+	return best
+}
+
+// record updates the consecutive failures of target i with the outcome of an attempt.
+func (t *Targets[T]) record(i int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.failures[i]++
+	} else {
+		t.failures[i] = 0
+	}
+}
+
+// DoAcross attempts f with retry logic, rotating to the next target on every retry.
+// Use Targets to keep the failure tracking across calls.
+func DoAcross[T any](ctx context.Context, targets []T, f func(ctx context.Context, target T) error, opts *Option) error {
+	return NewTargets(targets...).Do(ctx, f, opts)
+}
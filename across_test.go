@@ -0,0 +1,78 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDoAcross(t *testing.T) {
+	tests := []struct {
+		name         string
+		targets      []string
+		healthy      map[string]bool
+		wantAttempts []string
+		wantErr      error
+	}{
+		{
+			name:         "success on first target",
+			targets:      []string{"a", "b", "c"},
+			healthy:      map[string]bool{"a": true},
+			wantAttempts: []string{"a"},
+		},
+		{
+			name:         "rotates to the next targets",
+			targets:      []string{"a", "b", "c"},
+			healthy:      map[string]bool{"c": true},
+			wantAttempts: []string{"a", "b", "c"},
+		},
+		{
+			name:         "wraps around the targets",
+			targets:      []string{"a", "b"},
+			healthy:      map[string]bool{},
+			wantAttempts: []string{"a", "b", "a"},
+			wantErr:      ErrMaxRetriesExceeded,
+		},
+		{
+			name:    "fails without targets",
+			wantErr: ErrNoTargets,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []string
+			err := DoAcross(context.Background(), tt.targets, func(ctx context.Context, target string) error {
+				attempts = append(attempts, target)
+				if !tt.healthy[target] {
+					return errors.New("test-error")
+				}
+				return nil
+			}, &Option{MaxRetries: 3, Delay: 1 * time.Millisecond})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DoAcross() error = %v, want %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(attempts) != fmt.Sprint(tt.wantAttempts) {
+				t.Errorf("attempted targets = %v, want %v", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestTargets_Failures(t *testing.T) {
+	targets := NewTargets("a", "b", "c")
+	f := func(ctx context.Context, target string) error {
+		if target == "b" {
+			return nil
+		}
+		return errors.New("test-error")
+	}
+	opts := &Option{MaxRetries: 3, Delay: 1 * time.Millisecond}
+
+	_ = targets.Do(context.Background(), f, opts) // a fails, b succeeds
+	_ = targets.Do(context.Background(), f, opts) // c fails, b succeeds
+	if got := fmt.Sprint(targets.Failures()); got != "[1 0 1]" {
+		t.Errorf("Failures() = %v, want [1 0 1]", got)
+	}
+}
@@ -0,0 +1,123 @@
+package retry
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// AdaptiveOption configures the adaptive backoff of a Retryer.
+type AdaptiveOption struct {
+	Window        int     // Number of recent attempts used to compute the failure rate (default: 100)
+	Threshold     float64 // Failure rate above which delays widen and attempts are reduced (default: 0.5)
+	MaxMultiplier float64 // Factor applied to the delays when every recent attempt failed (default: 8)
+}
+
+// fillDefault will set required options with default value if it is not set.
+func (o *AdaptiveOption) fillDefault() {
+	if o.Window <= 0 {
+		o.Window = 100
+	}
+	if o.Threshold <= 0 || o.Threshold >= 1 {
+		o.Threshold = 0.5
+	}
+	if o.MaxMultiplier < 1 {
+		o.MaxMultiplier = 8
+	}
+}
+
+// AdaptiveState is a snapshot of the adaptive backoff of a Retryer.
+type AdaptiveState struct {
+	Samples         int     // Number of attempts in the window
+	FailureRate     float64 // Ratio of failed attempts in the window
+	DelayMultiplier float64 // Factor currently applied to the delays
+	MaxRetries      int     // Maximum number of attempts currently allowed, or Unlimited
+}
+
+// adaptive tracks the outcome of recent attempts in a sliding window.
+type adaptive struct {
+	opts     AdaptiveOption
+	mu       sync.Mutex
+	outcomes []bool // true for failed attempts, used as a ring buffer
+	next     int
+	samples  int
+	failures int
+}
+
+func newAdaptive(opts *AdaptiveOption) *adaptive {
+	var o AdaptiveOption
+	if opts != nil {
+		o = *opts
+	}
+	o.fillDefault()
+	return &adaptive{
+		opts:     o,
+		outcomes: make([]bool, o.Window),
+	}
+}
+
+// record adds the outcome of an attempt to the window.
+func (a *adaptive) record(failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.samples == len(a.outcomes) {
+		if a.outcomes[a.next] {
+			a.failures--
+		}
+	} else {
+		a.samples++
+	}
+	a.outcomes[a.next] = failed
+	if failed {
+		a.failures++
+	}
+	a.next = (a.next + 1) % len(a.outcomes)
+}
+
+// state computes the adjustment of a policy allowing maxRetries attempts.
+func (a *adaptive) state(maxRetries int) AdaptiveState {
+	a.mu.Lock()
+	samples, failures := a.samples, a.failures
+	a.mu.Unlock()
+
+	s := AdaptiveState{
+		Samples:         samples,
+		DelayMultiplier: 1,
+		MaxRetries:      maxRetries,
+	}
+	if samples == 0 {
+		return s
+	}
+	s.FailureRate = float64(failures) / float64(samples)
+	if s.FailureRate <= a.opts.Threshold {
+		return s
+	}
+
+	// severity grows linearly from 0 at the threshold to 1 when every attempt failed.
+	severity := (s.FailureRate - a.opts.Threshold) / (1 - a.opts.Threshold)
+	s.DelayMultiplier = 1 + severity*(a.opts.MaxMultiplier-1)
+	if maxRetries == Unlimited {
+		return s
+	}
+	s.MaxRetries = int(math.Round(float64(maxRetries) * (1 - severity)))
+	if s.MaxRetries < 1 {
+		s.MaxRetries = 1
+	}
+	return s
+}
+
+// apply adjusts opts to the current state and records the attempts of f.
+func (a *adaptive) apply(opts *Option, f func() error) func() error {
+	opts.fillDefault()
+	s := a.state(opts.MaxRetries)
+	opts.MaxRetries = s.MaxRetries
+	opts.Delay = time.Duration(float64(opts.Delay) * s.DelayMultiplier)
+	if opts.MaxDelay > 0 {
+		opts.MaxDelay = time.Duration(float64(opts.MaxDelay) * s.DelayMultiplier)
+	}
+	return func() error {
+		err := f()
+		a.record(err != nil)
+		return err
+	}
+}
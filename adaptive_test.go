@@ -0,0 +1,77 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdaptive_state(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []bool
+		want     AdaptiveState
+	}{
+		{
+			name:     "no samples",
+			outcomes: nil,
+			want:     AdaptiveState{DelayMultiplier: 1, MaxRetries: 4},
+		},
+		{
+			name:     "failure rate below threshold",
+			outcomes: []bool{true, false, false, false},
+			want:     AdaptiveState{Samples: 4, FailureRate: 0.25, DelayMultiplier: 1, MaxRetries: 4},
+		},
+		{
+			name:     "failure rate above threshold",
+			outcomes: []bool{true, true, true, false},
+			want:     AdaptiveState{Samples: 4, FailureRate: 0.75, DelayMultiplier: 3, MaxRetries: 2},
+		},
+		{
+			name:     "every attempt failed",
+			outcomes: []bool{true, true, true, true},
+			want:     AdaptiveState{Samples: 4, FailureRate: 1, DelayMultiplier: 5, MaxRetries: 1},
+		},
+		{
+			name:     "recovered downstream slides out failures",
+			outcomes: []bool{true, true, true, true, false, false, false, false},
+			want:     AdaptiveState{Samples: 4, FailureRate: 0, DelayMultiplier: 1, MaxRetries: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAdaptive(&AdaptiveOption{Window: 4, Threshold: 0.5, MaxMultiplier: 5})
+			for _, failed := range tt.outcomes {
+				a.record(failed)
+			}
+			if got := a.state(4); got != tt.want {
+				t.Errorf("state() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryer_EnableAdaptive(t *testing.T) {
+	r := NewRetryer(NewPolicy().MaxRetries(4).Constant(1 * time.Millisecond).Build())
+	r.EnableAdaptive(&AdaptiveOption{Window: 4})
+
+	attempts := 0
+	f := func() error {
+		attempts++
+		return errors.New("test-error")
+	}
+	_ = r.Do(context.Background(), f)
+	if attempts != 4 {
+		t.Errorf("attempts = %d, want 4", attempts)
+	}
+
+	attempts = 0
+	_ = r.Do(context.Background(), f)
+	if attempts != 1 {
+		t.Errorf("attempts while failing = %d, want 1", attempts)
+	}
+	if s := r.AdaptiveState(); s.FailureRate != 1 || s.MaxRetries != 1 {
+		t.Errorf("AdaptiveState() = %+v, want failure rate 1 and 1 attempt", s)
+	}
+}
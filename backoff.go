@@ -0,0 +1,196 @@
+package retry
+
+import (
+	"math/rand"
+	"time"
+)
+
+// exhaustion tells why a Backoff allows no more attempts.
+type exhaustion int
+
+const (
+	notExhausted exhaustion = iota
+	maxRetriesReached
+	timeoutReached
+)
+
+// Backoff is the scheduling engine of Do exposed as an iterator, for callers that
+// cannot hand control to Do, such as select-loop based workers. It yields the same
+// delay sequence, jitter, caps and budget accounting as Do:
+//
+//	b := retry.NewBackoff(opts)
+//	time.Sleep(b.Initial())
+//	for {
+//		if err := op(); err == nil {
+//			break
+//		}
+//		d, ok := b.Next()
+//		if !ok {
+//			break
+//		}
+//		time.Sleep(d)
+//	}
+//
+// A Backoff is not safe for concurrent use.
+type Backoff struct {
+	opts       *Option
+	attempts   int
+	totalDelay time.Duration
+	delay      time.Duration
+	initial    time.Duration            // delay returned by Initial
+	hasInitial bool                     // whether Initial scheduled its delay
+	classes    map[scheduleKey]*Backoff // delay schedules of the policies returned by PolicyForError
+}
+
+// NewBackoff creates a Backoff using the scheduling fields of opts.
+func NewBackoff(opts *Option) *Backoff {
+	var o Option
+	if opts != nil {
+		o = *opts
+	}
+	o.fillDefault()
+	b := &Backoff{opts: &o}
+	b.Reset()
+	return b
+}
+
+// Reset restarts the schedule from the first attempt.
+func (b *Backoff) Reset() {
+	b.attempts = 0
+	b.totalDelay = 0
+	b.delay = b.opts.Delay
+	b.initial, b.hasInitial = 0, false
+	b.classes = nil
+}
+
+// Initial returns the delay to wait before the first attempt: InitialDelay, or the
+// first backoff delay when DelayBeforeRetry is set, and 0 otherwise. The delay is
+// scheduled by the first call; later calls return the same delay until Reset.
+func (b *Backoff) Initial() time.Duration {
+	if b.hasInitial {
+		return b.initial
+	}
+	var d time.Duration
+	if b.opts.InitialDelay > 0 {
+		d = b.opts.InitialDelay
+	} else if b.opts.DelayBeforeRetry {
+		d = b.advance()
+	}
+	b.totalDelay += d
+	b.initial, b.hasInitial = d, true
+	return d
+}
+
+// Next records a failed attempt and returns the delay to wait before the next one.
+// It returns false when MaxRetries attempts were made or the Timeout budget is spent.
+func (b *Backoff) Next() (time.Duration, bool) {
+	d, reason := b.next(nil)
+	return d, reason == notExhausted
+}
+
+// NextFor is like Next but takes the delay from the policy PolicyForError returns for
+// err, if any, so every error class follows its own delay schedule.
+func (b *Backoff) NextFor(err error) (time.Duration, bool) {
+	d, reason := b.next(err)
+	return d, reason == notExhausted
+}
+
+// Attempts returns the number of failed attempts recorded by Next.
+func (b *Backoff) Attempts() int {
+	return b.attempts
+}
+
+// TotalDelay returns the sum of the delays returned so far.
+func (b *Backoff) TotalDelay() time.Duration {
+	return b.totalDelay
+}
+
+// next is NextFor reporting why no more attempts are allowed.
+func (b *Backoff) next(err error) (time.Duration, exhaustion) {
+	b.attempts++
+	if b.opts.MaxRetries != Unlimited && b.attempts >= b.opts.MaxRetries {
+		return 0, maxRetriesReached
+	}
+	if b.opts.Timeout != Unlimited && b.totalDelay >= b.opts.Timeout {
+		return 0, timeoutReached
+	}
+	d := b.schedule(err).advance()
+	b.totalDelay += d
+	return d, notExhausted
+}
+
+// schedule returns the Backoff whose delay sequence applies to err.
+func (b *Backoff) schedule(err error) *Backoff {
+	if err == nil || b.opts.PolicyForError == nil {
+		return b
+	}
+	p := b.opts.PolicyForError(err)
+	if p == nil {
+		return b
+	}
+	key := scheduleKey{
+		delay:                 p.Delay,
+		maxDelay:              p.MaxDelay,
+		useExponential:        p.UseExponential,
+		useJitter:             p.UseJitter,
+		useDecorrelatedJitter: p.UseDecorrelatedJitter,
+	}
+	class, ok := b.classes[key]
+	if !ok {
+		opts := p.Option()
+		opts.JitterSource = b.opts.JitterSource
+		class = NewBackoff(opts)
+		if b.classes == nil {
+			b.classes = make(map[scheduleKey]*Backoff)
+		}
+		b.classes[key] = class
+	}
+	return class
+}
+
+// scheduleKey identifies the delay schedule of a Policy, so policies built anew for
+// every error still share their schedule.
+type scheduleKey struct {
+	delay                 time.Duration
+	maxDelay              time.Duration
+	useExponential        bool
+	useJitter             bool
+	useDecorrelatedJitter bool
+}
+
+// advance returns the current delay and moves the schedule to the next one.
+func (b *Backoff) advance() time.Duration {
+	if b.opts.UseDecorrelatedJitter {
+		// decorrelated jitter: a random delay between Delay and three times the previous one.
+		upper := b.delay * 3
+		if upper < b.opts.Delay {
+			upper = b.opts.Delay
+		}
+		if b.opts.MaxDelay > 0 && upper > b.opts.MaxDelay {
+			upper = b.opts.MaxDelay
+		}
+		d := b.opts.Delay + time.Duration(b.random()*float64(upper-b.opts.Delay+1))
+		b.delay = d
+		return d
+	}
+	if b.opts.UseJitter {
+		jitter := b.random()*1.0 + 0.5
+		b.delay = time.Duration(float64(b.delay) * jitter)
+	}
+	if b.opts.MaxDelay > 0 && b.delay > b.opts.MaxDelay {
+		b.delay = b.opts.MaxDelay
+	}
+	d := b.delay
+	if b.opts.UseExponential {
+		b.delay = b.delay * 2
+	}
+	return d
+}
+
+// random returns a random number in [0, 1) from JitterSource or math/rand.
+func (b *Backoff) random() float64 {
+	if b.opts.JitterSource != nil {
+		return b.opts.JitterSource()
+	}
+	return rand.Float64()
+}
@@ -0,0 +1,206 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff_Next(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        *Option
+		wantInitial time.Duration
+		wantDelays  []time.Duration
+	}{
+		{
+			name:       "default options",
+			opts:       nil,
+			wantDelays: []time.Duration{1 * time.Second, 1 * time.Second},
+		},
+		{
+			name: "exponential capped by max delay",
+			opts: &Option{
+				MaxRetries:     5,
+				Delay:          1 * time.Second,
+				MaxDelay:       3 * time.Second,
+				Timeout:        1 * time.Minute,
+				UseExponential: true,
+			},
+			wantDelays: []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name: "stops when timeout budget is spent",
+			opts: &Option{
+				MaxRetries:     10,
+				Delay:          1 * time.Second,
+				Timeout:        3 * time.Second,
+				UseExponential: true,
+			},
+			wantDelays: []time.Duration{1 * time.Second, 2 * time.Second},
+		},
+		{
+			name: "unlimited retries bounded by timeout",
+			opts: &Option{
+				MaxRetries: Unlimited,
+				Delay:      1 * time.Second,
+				Timeout:    5 * time.Second,
+			},
+			wantDelays: []time.Duration{1 * time.Second, 1 * time.Second, 1 * time.Second, 1 * time.Second, 1 * time.Second},
+		},
+		{
+			name: "unlimited timeout bounded by max retries",
+			opts: &Option{
+				MaxRetries:     4,
+				Delay:          1 * time.Hour,
+				Timeout:        Unlimited,
+				UseExponential: true,
+			},
+			wantDelays: []time.Duration{1 * time.Hour, 2 * time.Hour, 4 * time.Hour},
+		},
+		{
+			name: "delay before first attempt",
+			opts: &Option{
+				MaxRetries:       3,
+				Delay:            1 * time.Second,
+				Timeout:          1 * time.Minute,
+				UseExponential:   true,
+				DelayBeforeRetry: true,
+			},
+			wantInitial: 1 * time.Second,
+			wantDelays:  []time.Duration{2 * time.Second, 4 * time.Second},
+		},
+		{
+			name: "jitter from jitter source",
+			opts: &Option{
+				MaxRetries:     4,
+				Delay:          1 * time.Second,
+				Timeout:        1 * time.Minute,
+				UseExponential: true,
+				UseJitter:      true,
+				JitterSource:   func() float64 { return 0.5 }, // a jitter factor of 1
+			},
+			wantDelays: []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name: "decorrelated jitter from jitter source",
+			opts: &Option{
+				MaxRetries:            3,
+				Delay:                 1 * time.Second,
+				Timeout:               1 * time.Minute,
+				UseDecorrelatedJitter: true,
+				JitterSource:          func() float64 { return 0 },
+			},
+			wantDelays: []time.Duration{1 * time.Second, 1 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff(tt.opts)
+			for i := 0; i < 2; i++ {
+				if got := b.Initial(); got != tt.wantInitial {
+					t.Errorf("Initial() #%d = %v, want %v", i+1, got, tt.wantInitial)
+				}
+			}
+			if b.TotalDelay() != tt.wantInitial {
+				t.Errorf("TotalDelay() after Initial() = %v, want %v", b.TotalDelay(), tt.wantInitial)
+			}
+			var got []time.Duration
+			for d, ok := b.Next(); ok; d, ok = b.Next() {
+				got = append(got, d)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantDelays) {
+				t.Errorf("Next() delays = %v, want %v", got, tt.wantDelays)
+			}
+
+			b.Reset()
+			if d, ok := b.Next(); len(tt.wantDelays) > 0 && tt.wantInitial == 0 && (!ok || d != tt.wantDelays[0]) {
+				t.Errorf("Next() after Reset() = %v, %v, want %v", d, ok, tt.wantDelays[0])
+			}
+		})
+	}
+}
+
+func TestBackoff_decorrelatedJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		maxDelay time.Duration
+		wantMin  time.Duration
+	}{
+		{
+			name:     "capped by max delay",
+			delay:    1 * time.Second,
+			maxDelay: 20 * time.Second,
+			wantMin:  1 * time.Second,
+		},
+		{
+			name:     "max delay below delay",
+			delay:    1 * time.Second,
+			maxDelay: 100 * time.Millisecond,
+			wantMin:  100 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff(&Option{
+				MaxRetries:            Unlimited,
+				Delay:                 tt.delay,
+				MaxDelay:              tt.maxDelay,
+				Timeout:               Unlimited,
+				UseDecorrelatedJitter: true,
+			})
+			prev := tt.wantMin
+			for i := 0; i < 100; i++ {
+				d, ok := b.Next()
+				if !ok || d < tt.wantMin || d > 3*prev || d > tt.maxDelay {
+					t.Fatalf("Next() = %v, %v, want between %v and min(%v, %v)", d, ok, tt.wantMin, 3*prev, tt.maxDelay)
+				}
+				prev = d
+			}
+		})
+	}
+}
+
+func TestBackoff_NextFor(t *testing.T) {
+	var (
+		errThrottled = errors.New("throttled")
+		errReset     = errors.New("connection reset")
+		throttled    = NewPolicy().Constant(5 * time.Second).Build()
+	)
+	b := NewBackoff(&Option{
+		MaxRetries:     Unlimited,
+		Delay:          1 * time.Second,
+		Timeout:        Unlimited,
+		UseExponential: true,
+		PolicyForError: func(err error) *Policy {
+			switch {
+			case errors.Is(err, errThrottled):
+				return throttled
+			case errors.Is(err, errReset):
+				return NewPolicy().Exponential(100 * time.Millisecond).Build() // a new *Policy on every call
+
+			}
+			return nil
+		},
+	})
+
+	errs := []error{errReset, errReset, errThrottled, errReset, errors.New("other"), errThrottled}
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		5 * time.Second,
+		400 * time.Millisecond,
+		1 * time.Second,
+		5 * time.Second,
+	}
+	for i, err := range errs {
+		if d, ok := b.NextFor(err); !ok || d != want[i] {
+			t.Errorf("NextFor(%v) #%d = %v, %v, want %v", err, i, d, ok, want[i])
+		}
+	}
+	if len(b.classes) != 2 {
+		t.Errorf("len(classes) = %d, want 2", len(b.classes))
+	}
+}
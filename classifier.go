@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/rizanw/go-retry/internal/errcode"
+)
+
+// Verdict is the decision of a Classifier about an error.
+type Verdict int
+
+const (
+	Unknown      Verdict = iota // The classifier does not recognize the error
+	Retryable                   // The error is transient and worth retrying
+	NonRetryable                // Retrying will not help
+)
+
+// Classifier decides whether an error is worth retrying.
+type Classifier interface {
+	Classify(err error) Verdict
+}
+
+// ClassifierFunc adapts a function to a Classifier.
+type ClassifierFunc func(err error) Verdict
+
+// Classify calls f(err).
+func (f ClassifierFunc) Classify(err error) Verdict {
+	return f(err)
+}
+
+// Classifiers combines classifiers; the first verdict other than Unknown wins.
+func Classifiers(cs ...Classifier) Classifier {
+	return ClassifierFunc(func(err error) Verdict {
+		for _, c := range cs {
+			if v := c.Classify(err); v != Unknown {
+				return v
+			}
+		}
+		return Unknown
+	})
+}
+
+// DefaultClassifier combines all built-in classifiers.
+var DefaultClassifier = Classifiers(
+	ContextClassifier,
+	HTTPClassifier,
+	GRPCClassifier,
+	AWSClassifier,
+	PostgresClassifier,
+	MySQLClassifier,
+	NetClassifier,
+)
+
+// ContextClassifier treats context.Canceled as non-retryable and context.DeadlineExceeded,
+// e.g. of a per-attempt timeout, as retryable.
+var ContextClassifier = ClassifierFunc(func(err error) Verdict {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return NonRetryable
+	case errors.Is(err, context.DeadlineExceeded):
+		return Retryable
+	}
+	return Unknown
+})
+
+// NetClassifier treats network timeouts, refused or reset connections and unexpected EOFs as retryable.
+var NetClassifier = ClassifierFunc(func(err error) Verdict {
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return Retryable
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return Retryable
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return Retryable
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return Retryable
+	}
+	return Unknown
+})
+
+// HTTPClassifier classifies errors exposing a StatusCode() int method. Request timeouts,
+// throttling and server errors are retryable, other client errors are not.
+var HTTPClassifier = ClassifierFunc(func(err error) Verdict {
+	var statusErr interface{ StatusCode() int }
+	if !errors.As(err, &statusErr) {
+		return Unknown
+	}
+	switch code := statusErr.StatusCode(); {
+	case code == 408, code == 425, code == 429:
+		return Retryable
+	case code == 501, code == 505:
+		return NonRetryable
+	case code >= 500:
+		return Retryable
+	case code >= 400:
+		return NonRetryable
+	}
+	return Unknown
+})
+
+// GRPCClassifier classifies errors of google.golang.org/grpc by status code. The code
+// is read by reflection to avoid depending on gRPC.
+var GRPCClassifier = ClassifierFunc(func(err error) Verdict {
+	code, ok := errcode.GRPCCode(err)
+	if !ok {
+		return Unknown
+	}
+	switch code {
+	case 4, 8, 10, 14: // DeadlineExceeded, ResourceExhausted, Aborted, Unavailable
+		return Retryable
+	case 1, 3, 5, 6, 7, 9, 11, 12, 16: // Canceled, InvalidArgument, NotFound, AlreadyExists, PermissionDenied,
+		// FailedPrecondition, OutOfRange, Unimplemented, Unauthenticated
+		return NonRetryable
+	}
+	return Unknown
+})
+
+// awsRetryableCodes are the throttling and transient error codes of the AWS SDKs.
+var awsRetryableCodes = map[string]struct{}{
+	"Throttling":                             {},
+	"ThrottlingException":                    {},
+	"ThrottledException":                     {},
+	"RequestThrottledException":              {},
+	"TooManyRequestsException":               {},
+	"ProvisionedThroughputExceededException": {},
+	"TransactionInProgressException":         {},
+	"RequestLimitExceeded":                   {},
+	"BandwidthLimitExceeded":                 {},
+	"LimitExceededException":                 {},
+	"RequestThrottled":                       {},
+	"SlowDown":                               {},
+	"PriorRequestNotComplete":                {},
+	"EC2ThrottledException":                  {},
+	"RequestTimeout":                         {},
+	"RequestTimeoutException":                {},
+	"InternalError":                          {},
+	"ServiceUnavailable":                     {},
+}
+
+// AWSClassifier treats throttling and transient errors of the AWS SDKs, recognized by
+// their ErrorCode() string method, as retryable.
+var AWSClassifier = ClassifierFunc(func(err error) Verdict {
+	var awsErr interface{ ErrorCode() string }
+	if !errors.As(err, &awsErr) {
+		return Unknown
+	}
+	if _, ok := awsRetryableCodes[awsErr.ErrorCode()]; ok {
+		return Retryable
+	}
+	return Unknown
+})
+
+// PostgresClassifier classifies Postgres errors by SQLSTATE. Serialization failures,
+// deadlocks, connection exceptions and resource shortages are retryable; data,
+// integrity and syntax errors are not.
+var PostgresClassifier = ClassifierFunc(func(err error) Verdict {
+	state, ok := errcode.SQLState(err)
+	if !ok {
+		return Unknown
+	}
+	switch {
+	case state == "40001", state == "40P01", state == "55P03", state == "57P01", state == "57P02", state == "57P03":
+		return Retryable
+	case strings.HasPrefix(state, "08"), strings.HasPrefix(state, "53"):
+		return Retryable
+	case strings.HasPrefix(state, "22"), strings.HasPrefix(state, "23"), strings.HasPrefix(state, "42"):
+		return NonRetryable
+	}
+	return Unknown
+})
+
+// MySQLClassifier classifies github.com/go-sql-driver/mysql errors by number. Deadlocks,
+// lock wait timeouts and lost connections are retryable; duplicate keys and syntax
+// errors are not.
+var MySQLClassifier = ClassifierFunc(func(err error) Verdict {
+	number, ok := errcode.MySQLNumber(err)
+	if !ok {
+		return Unknown
+	}
+	switch number {
+	case 1040, 1205, 1213, 2006, 2013:
+		return Retryable
+	case 1062, 1064, 1146, 1452:
+		return NonRetryable
+	}
+	return Unknown
+})
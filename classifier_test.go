@@ -0,0 +1,103 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type testStatusError struct{ code int }
+
+func (e testStatusError) Error() string { return fmt.Sprintf("status %d", e.code) }
+
+func (e testStatusError) StatusCode() int { return e.code }
+
+type testGRPCCode uint32
+
+type testGRPCStatus struct{ code testGRPCCode }
+
+func (s *testGRPCStatus) Code() testGRPCCode { return s.code }
+
+type testGRPCError struct{ code testGRPCCode }
+
+func (e testGRPCError) Error() string { return fmt.Sprintf("grpc code %d", e.code) }
+
+func (e testGRPCError) GRPCStatus() *testGRPCStatus { return &testGRPCStatus{code: e.code} }
+
+type testAWSError struct{ code string }
+
+func (e testAWSError) Error() string { return "aws " + e.code }
+
+func (e testAWSError) ErrorCode() string { return e.code }
+
+type testSQLStateError struct{ state string }
+
+func (e testSQLStateError) Error() string { return "sqlstate " + e.state }
+
+func (e testSQLStateError) SQLState() string { return e.state }
+
+type testMySQLError struct{ Number uint16 }
+
+func (e *testMySQLError) Error() string { return fmt.Sprintf("Error %d", e.Number) }
+
+type testNetError struct{ timeout bool }
+
+func (e testNetError) Error() string { return "net error" }
+
+func (e testNetError) Timeout() bool { return e.timeout }
+
+func (e testNetError) Temporary() bool { return false }
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Verdict
+	}{
+		{name: "unknown error", err: errors.New("test-error"), want: Unknown},
+		{name: "context canceled", err: fmt.Errorf("call: %w", context.Canceled), want: NonRetryable},
+		{name: "context deadline exceeded", err: context.DeadlineExceeded, want: Retryable},
+		{name: "net timeout", err: testNetError{timeout: true}, want: Retryable},
+		{name: "http too many requests", err: testStatusError{code: 429}, want: Retryable},
+		{name: "http service unavailable", err: testStatusError{code: 503}, want: Retryable},
+		{name: "http bad request", err: testStatusError{code: 400}, want: NonRetryable},
+		{name: "grpc unavailable", err: testGRPCError{code: 14}, want: Retryable},
+		{name: "grpc invalid argument", err: testGRPCError{code: 3}, want: NonRetryable},
+		{name: "aws throttling", err: testAWSError{code: "ThrottlingException"}, want: Retryable},
+		{name: "aws unknown code", err: testAWSError{code: "AccessDenied"}, want: Unknown},
+		{name: "postgres serialization failure", err: testSQLStateError{state: "40001"}, want: Retryable},
+		{name: "postgres unique violation", err: testSQLStateError{state: "23505"}, want: NonRetryable},
+		{name: "mysql deadlock", err: fmt.Errorf("exec: %w", &testMySQLError{Number: 1213}), want: Retryable},
+		{name: "mysql duplicate key", err: &testMySQLError{Number: 1062}, want: NonRetryable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultClassifier.Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo_classifier(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return testStatusError{code: 503}
+		}
+		return testStatusError{code: 400}
+	}, &Option{
+		MaxRetries: 5,
+		Delay:      1 * time.Millisecond,
+		Classifier: DefaultClassifier,
+	})
+	if err == nil {
+		t.Fatal("Do() error = nil, want error")
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
@@ -0,0 +1,277 @@
+package retry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	backoffConstant     = "constant"
+	backoffExponential  = "exponential"
+	backoffDecorrelated = "decorrelated"
+
+	// unlimited is the duration value of Unlimited in configuration files.
+	unlimited = "unlimited"
+)
+
+// policyConfig is the configuration file representation of a Policy.
+// Durations are strings accepted by time.ParseDuration, e.g. "100ms".
+type policyConfig struct {
+	MaxRetries     retryCount `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+	Backoff        string     `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	Delay          string     `json:"delay,omitempty" yaml:"delay,omitempty"`
+	MaxDelay       string     `json:"max_delay,omitempty" yaml:"max_delay,omitempty"`
+	Timeout        string     `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Jitter         bool       `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+	RetryableCodes []string   `json:"retryable_codes,omitempty" yaml:"retryable_codes,omitempty"`
+}
+
+// ParsePolicy parses a JSON encoded policy. Use "unlimited" (or -1 for max_retries) for
+// max_retries or timeout to disable that bound. Unknown fields are rejected, so typos
+// do not silently keep the defaults. For example:
+//
+//	{"max_retries": 5, "backoff": "exponential", "delay": "100ms", "max_delay": "5s",
+//	 "timeout": "30s", "jitter": true, "retryable_codes": ["429", "503"]}
+func ParsePolicy(data []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PolicyFromEnv reads a policy from the environment variables prefix_MAX_RETRIES,
+// prefix_BACKOFF, prefix_DELAY, prefix_MAX_DELAY, prefix_TIMEOUT, prefix_JITTER and
+// prefix_RETRYABLE_CODES (comma separated).
+func PolicyFromEnv(prefix string) (*Policy, error) {
+	var (
+		c   policyConfig
+		err error
+	)
+	if v, ok := os.LookupEnv(prefix + "_MAX_RETRIES"); ok {
+		if err = c.MaxRetries.parse(v); err != nil {
+			return nil, fmt.Errorf("parse %s_MAX_RETRIES: %w", prefix, err)
+		}
+	}
+	if v, ok := os.LookupEnv(prefix + "_JITTER"); ok {
+		if c.Jitter, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("parse %s_JITTER: %w", prefix, err)
+		}
+	}
+	if v, ok := os.LookupEnv(prefix + "_RETRYABLE_CODES"); ok && v != "" {
+		for _, code := range strings.Split(v, ",") {
+			c.RetryableCodes = append(c.RetryableCodes, strings.TrimSpace(code))
+		}
+	}
+	c.Backoff = os.Getenv(prefix + "_BACKOFF")
+	c.Delay = os.Getenv(prefix + "_DELAY")
+	c.MaxDelay = os.Getenv(prefix + "_MAX_DELAY")
+	c.Timeout = os.Getenv(prefix + "_TIMEOUT")
+
+	p := &Policy{}
+	if err = p.fromConfig(c); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UnmarshalJSON decodes the configuration file representation of a Policy. Unknown
+// fields are rejected.
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	var c policyConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return err
+	}
+	return p.fromConfig(c)
+}
+
+// MarshalJSON encodes the configuration file representation of a Policy.
+func (p Policy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.toConfig())
+}
+
+// UnmarshalYAML decodes the configuration file representation of a Policy.
+// It is compatible with gopkg.in/yaml.v2 and gopkg.in/yaml.v3.
+func (p *Policy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var c policyConfig
+	if err := unmarshal(&c); err != nil {
+		return err
+	}
+	return p.fromConfig(c)
+}
+
+// MarshalYAML encodes the configuration file representation of a Policy, with
+// durations as strings. It is compatible with gopkg.in/yaml.v2 and gopkg.in/yaml.v3.
+func (p Policy) MarshalYAML() (interface{}, error) {
+	return p.toConfig(), nil
+}
+
+func (p *Policy) fromConfig(c policyConfig) error {
+	var (
+		np  = Policy{MaxRetries: int(c.MaxRetries), UseJitter: c.Jitter, RetryableCodes: c.RetryableCodes}
+		err error
+	)
+	switch strings.ToLower(c.Backoff) {
+	case "", backoffConstant:
+	case backoffExponential:
+		np.UseExponential = true
+	case backoffDecorrelated:
+		np.UseDecorrelatedJitter = true
+	default:
+		return fmt.Errorf("unknown backoff %q", c.Backoff)
+	}
+	if np.Delay, err = parseDuration("delay", c.Delay); err != nil {
+		return err
+	}
+	if np.MaxDelay, err = parseDuration("max_delay", c.MaxDelay); err != nil {
+		return err
+	}
+	if np.Timeout, err = parseDuration("timeout", c.Timeout); err != nil {
+		return err
+	}
+	*p = np
+	return nil
+}
+
+func (p Policy) toConfig() policyConfig {
+	c := policyConfig{
+		MaxRetries:     retryCount(p.MaxRetries),
+		Backoff:        backoffConstant,
+		Jitter:         p.UseJitter,
+		RetryableCodes: p.RetryableCodes,
+	}
+	if p.UseExponential {
+		c.Backoff = backoffExponential
+	}
+	if p.UseDecorrelatedJitter {
+		c.Backoff = backoffDecorrelated
+	}
+	if p.Delay > 0 {
+		c.Delay = p.Delay.String()
+	}
+	if p.MaxDelay > 0 {
+		c.MaxDelay = p.MaxDelay.String()
+	}
+	if p.Timeout < 0 {
+		c.Timeout = unlimited
+	} else if p.Timeout > 0 {
+		c.Timeout = p.Timeout.String()
+	}
+	return c
+}
+
+// retryCount is the max_retries value of configuration files: a number or "unlimited".
+type retryCount int
+
+func (n *retryCount) parse(s string) error {
+	if strings.EqualFold(s, unlimited) {
+		*n = Unlimited
+		return nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*n = retryCount(i)
+	return nil
+}
+
+func (n *retryCount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(data)
+	}
+	if err := n.parse(s); err != nil {
+		return fmt.Errorf("parse max_retries: %w", err)
+	}
+	return nil
+}
+
+func (n retryCount) MarshalJSON() ([]byte, error) {
+	if n < 0 {
+		return json.Marshal(unlimited)
+	}
+	return json.Marshal(int(n))
+}
+
+func (n *retryCount) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	if err := n.parse(s); err != nil {
+		return fmt.Errorf("parse max_retries: %w", err)
+	}
+	return nil
+}
+
+func (n retryCount) MarshalYAML() (interface{}, error) {
+	if n < 0 {
+		return unlimited, nil
+	}
+	return int(n), nil
+}
+
+func parseDuration(name, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if strings.EqualFold(s, unlimited) {
+		return Unlimited, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", name, err)
+	}
+	return d, nil
+}
+
+// retryableCodes returns a RetryIf function that only retries errors carrying one of
+// the codes. Errors without a code are retried.
+func retryableCodes(codes []string) func(err error) bool {
+	if len(codes) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(codes))
+	for _, code := range codes {
+		set[code] = struct{}{}
+	}
+	return func(err error) bool {
+		code, ok := errorCode(err)
+		if !ok {
+			return true
+		}
+		_, ok = set[code]
+		return ok
+	}
+}
+
+// errorCode extracts a code from errors exposing one of the common code methods,
+// e.g. HTTP status codes, AWS error codes and SQLSTATE codes.
+func errorCode(err error) (string, bool) {
+	var (
+		statusErr interface{ StatusCode() int }
+		awsErr    interface{ ErrorCode() string }
+		sqlErr    interface{ SQLState() string }
+		codeErr   interface{ Code() string }
+	)
+	switch {
+	case errors.As(err, &statusErr):
+		return strconv.Itoa(statusErr.StatusCode()), true
+	case errors.As(err, &awsErr):
+		return awsErr.ErrorCode(), true
+	case errors.As(err, &sqlErr):
+		return sqlErr.SQLState(), true
+	case errors.As(err, &codeErr):
+		return codeErr.Code(), true
+	}
+	return "", false
+}
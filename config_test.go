@@ -0,0 +1,288 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCodeError struct {
+	code string
+}
+
+func (e testCodeError) Error() string { return "code " + e.code }
+
+func (e testCodeError) Code() string { return e.code }
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *Policy
+		wantErr bool
+	}{
+		{
+			name: "full policy",
+			data: `{"max_retries": 5, "backoff": "exponential", "delay": "100ms", "max_delay": "5s",
+				"timeout": "30s", "jitter": true, "retryable_codes": ["429", "503"]}`,
+			want: &Policy{
+				MaxRetries:     5,
+				Delay:          100 * time.Millisecond,
+				MaxDelay:       5 * time.Second,
+				Timeout:        30 * time.Second,
+				UseExponential: true,
+				UseJitter:      true,
+				RetryableCodes: []string{"429", "503"},
+			},
+		},
+		{
+			name: "constant backoff",
+			data: `{"max_retries": 2, "backoff": "constant", "delay": "1s"}`,
+			want: &Policy{
+				MaxRetries: 2,
+				Delay:      1 * time.Second,
+			},
+		},
+		{
+			name: "unlimited bounds",
+			data: `{"max_retries": -1, "timeout": "unlimited"}`,
+			want: &Policy{
+				MaxRetries: Unlimited,
+				Timeout:    Unlimited,
+			},
+		},
+		{
+			name:    "fails on unknown backoff",
+			data:    `{"backoff": "fibonacci"}`,
+			wantErr: true,
+		},
+		{
+			name: "unlimited max retries",
+			data: `{"max_retries": "unlimited", "delay": "1s"}`,
+			want: &Policy{
+				MaxRetries: Unlimited,
+				Delay:      1 * time.Second,
+			},
+		},
+		{
+			name:    "fails on unknown field",
+			data:    `{"max_retry": 5}`,
+			wantErr: true,
+		},
+		{
+			name:    "fails on invalid max retries",
+			data:    `{"max_retries": "many"}`,
+			wantErr: true,
+		},
+		{
+			name:    "fails on invalid duration",
+			data:    `{"delay": "soon"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePolicy([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePolicy() = %+v, want %+v", got, tt.want)
+			}
+			if got == nil {
+				return
+			}
+			data, err := got.MarshalJSON()
+			if err != nil {
+				t.Fatalf("MarshalJSON() error = %v", err)
+			}
+			if again, _ := ParsePolicy(data); !reflect.DeepEqual(again, tt.want) {
+				t.Errorf("ParsePolicy(MarshalJSON()) = %+v, want %+v", again, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv("TEST_RETRY_MAX_RETRIES", "unlimited")
+	t.Setenv("TEST_RETRY_BACKOFF", "exponential")
+	t.Setenv("TEST_RETRY_DELAY", "200ms")
+	t.Setenv("TEST_RETRY_JITTER", "true")
+	t.Setenv("TEST_RETRY_RETRYABLE_CODES", "429, 503")
+
+	got, err := PolicyFromEnv("TEST_RETRY")
+	if err != nil {
+		t.Fatalf("PolicyFromEnv() error = %v", err)
+	}
+	want := &Policy{
+		MaxRetries:     Unlimited,
+		Delay:          200 * time.Millisecond,
+		UseExponential: true,
+		UseJitter:      true,
+		RetryableCodes: []string{"429", "503"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PolicyFromEnv() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPolicy_RetryableCodes(t *testing.T) {
+	retryIf := NewPolicy().RetryableCodes("503").Build().Option().RetryIf
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "retryable code",
+			err:  testCodeError{code: "503"},
+			want: true,
+		},
+		{
+			name: "non-retryable code",
+			err:  testCodeError{code: "400"},
+			want: false,
+		},
+		{
+			name: "error without code",
+			err:  errors.New("test-error"),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryIf(tt.err); got != tt.want {
+				t.Errorf("RetryIf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// yamlFields maps the yaml field names of a struct to its fields, like a YAML library does.
+func yamlFields(v reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+// fakeYAMLUnmarshal returns the unmarshal function a YAML library passes to UnmarshalYAML
+// for a document decoded into doc. Scalars are decoded into strings as text.
+func fakeYAMLUnmarshal(doc map[string]interface{}) func(interface{}) error {
+	return func(out interface{}) error {
+		fields := yamlFields(reflect.ValueOf(out).Elem())
+		for name, value := range doc {
+			field, ok := fields[name]
+			if !ok {
+				return errors.New("unknown field " + name)
+			}
+			if u, ok := field.Addr().Interface().(interface {
+				UnmarshalYAML(func(interface{}) error) error
+			}); ok {
+				if err := u.UnmarshalYAML(fakeYAMLScalar(value)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := fakeYAMLScalar(value)(field.Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// fakeYAMLScalar returns the unmarshal function for a single value.
+func fakeYAMLScalar(value interface{}) func(interface{}) error {
+	return func(out interface{}) error {
+		v := reflect.ValueOf(out).Elem()
+		if v.Kind() == reflect.String {
+			v.SetString(fmt.Sprint(value))
+			return nil
+		}
+		v.Set(reflect.ValueOf(value).Convert(v.Type()))
+		return nil
+	}
+}
+
+// fakeYAMLMarshal returns the document a YAML library encodes for the value of MarshalYAML.
+func fakeYAMLMarshal(v interface{}) map[string]interface{} {
+	doc := make(map[string]interface{})
+	for name, field := range yamlFields(reflect.ValueOf(v)) {
+		if field.IsZero() {
+			continue
+		}
+		if m, ok := field.Interface().(interface{ MarshalYAML() (interface{}, error) }); ok {
+			doc[name], _ = m.MarshalYAML()
+			continue
+		}
+		doc[name] = field.Interface()
+	}
+	return doc
+}
+
+func TestPolicy_YAML(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  map[string]interface{}
+		want Policy
+	}{
+		{
+			name: "full policy",
+			doc: map[string]interface{}{
+				"max_retries":     5,
+				"backoff":         "exponential",
+				"delay":           "100ms",
+				"max_delay":       "5s",
+				"timeout":         "30s",
+				"jitter":          true,
+				"retryable_codes": []string{"429", "503"},
+			},
+			want: Policy{
+				MaxRetries:     5,
+				Delay:          100 * time.Millisecond,
+				MaxDelay:       5 * time.Second,
+				Timeout:        30 * time.Second,
+				UseExponential: true,
+				UseJitter:      true,
+				RetryableCodes: []string{"429", "503"},
+			},
+		},
+		{
+			name: "unlimited bounds",
+			doc: map[string]interface{}{
+				"max_retries": "unlimited",
+				"backoff":     "constant",
+				"timeout":     "unlimited",
+			},
+			want: Policy{
+				MaxRetries: Unlimited,
+				Timeout:    Unlimited,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Policy
+			if err := got.UnmarshalYAML(fakeYAMLUnmarshal(tt.doc)); err != nil {
+				t.Fatalf("UnmarshalYAML() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalYAML() = %+v, want %+v", got, tt.want)
+			}
+
+			v, err := tt.want.MarshalYAML()
+			if err != nil {
+				t.Fatalf("MarshalYAML() error = %v", err)
+			}
+			if encoded := fakeYAMLMarshal(v); !reflect.DeepEqual(encoded, tt.doc) {
+				t.Errorf("MarshalYAML() = %v, want %v", encoded, tt.doc)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMaxRetriesExceeded is the reason of an *Error returned after MaxRetries failed attempts.
+	ErrMaxRetriesExceeded = errors.New("retry: max retries exceeded")
+	// ErrTimeoutExceeded is the reason of an *Error returned once the Timeout budget is spent.
+	ErrTimeoutExceeded = errors.New("retry: timeout exceeded")
+	// ErrAborted is the reason of an *Error returned when RetryIf or Classifier rejects an error.
+	ErrAborted = errors.New("retry: aborted on non-retryable error")
+	// ErrStopped is the reason of an *Error returned when retrying is stopped through Option.StopCh or Handle.Stop.
+	ErrStopped = errors.New("retry: stopped")
+	// ErrDeadlineWouldExceed is the reason of an *Error returned when the next attempt could not complete
+	// before the context deadline, instead of waiting for the delay and failing on the deadline anyway.
+	ErrDeadlineWouldExceed = errors.New("retry: next attempt would exceed the context deadline")
+)
+
+// Error is returned when retrying gives up. Use errors.Is with ErrMaxRetriesExceeded,
+// ErrTimeoutExceeded, ErrAborted, ErrStopped, ErrDeadlineWouldExceed or a context error
+// to tell why, and errors.As to read the attempts and total delay. It unwraps to the
+// error of the last attempt.
+type Error struct {
+	reason     error
+	last       error
+	attempts   int
+	totalDelay time.Duration
+}
+
+func newError(reason, last error, attempts int, totalDelay time.Duration) *Error {
+	return &Error{
+		reason:     reason,
+		last:       last,
+		attempts:   attempts,
+		totalDelay: totalDelay,
+	}
+}
+
+// Error formats the reason, the attempts and the error of the last attempt.
+func (e *Error) Error() string {
+	var msg string
+	switch e.reason {
+	case ErrMaxRetriesExceeded:
+		msg = fmt.Sprintf("retry failed after %d attempt(s) with total delay: %fs", e.attempts, e.totalDelay.Seconds())
+	case ErrTimeoutExceeded:
+		msg = fmt.Sprintf("retry failed after reach timeout with %d attempt(s) and total delay: %fs", e.attempts, e.totalDelay.Seconds())
+	case ErrAborted:
+		msg = fmt.Sprintf("retry aborted at %d attempt(s)", e.attempts)
+	default:
+		msg = fmt.Sprintf("retry gave up after %d attempt(s): %v", e.attempts, e.reason)
+	}
+	if e.last != nil {
+		msg += ": " + e.last.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error of the last attempt, or nil when no attempt failed.
+func (e *Error) Unwrap() error {
+	return e.last
+}
+
+// Is reports whether target is the reason retrying gave up.
+func (e *Error) Is(target error) bool {
+	return errors.Is(e.reason, target)
+}
+
+// Reason returns why retrying gave up: one of the sentinel errors of this package,
+// the context error or the error of the Limiter.
+func (e *Error) Reason() error {
+	return e.reason
+}
+
+// Attempts returns the number of attempts made.
+func (e *Error) Attempts() int {
+	return e.attempts
+}
+
+// TotalDelay returns the sum of the delays waited between attempts.
+func (e *Error) TotalDelay() time.Duration {
+	return e.totalDelay
+}
@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestError(t *testing.T) {
+	errTest := errors.New("test-error")
+	stopCh := make(chan struct{})
+	close(stopCh)
+
+	tests := []struct {
+		name           string
+		opts           *Option
+		deadline       time.Duration
+		stopAfter      time.Duration
+		wantReason     error
+		wantAttempts   int
+		wantTotalDelay time.Duration
+		wantLast       bool
+	}{
+		{
+			name:           "max retries exceeded",
+			opts:           &Option{MaxRetries: 2, Delay: 1 * time.Millisecond},
+			wantReason:     ErrMaxRetriesExceeded,
+			wantAttempts:   2,
+			wantTotalDelay: 1 * time.Millisecond,
+			wantLast:       true,
+		},
+		{
+			name:           "timeout exceeded",
+			opts:           &Option{MaxRetries: 10, Delay: 1 * time.Millisecond, Timeout: 2 * time.Millisecond},
+			wantReason:     ErrTimeoutExceeded,
+			wantAttempts:   3,
+			wantTotalDelay: 2 * time.Millisecond,
+			wantLast:       true,
+		},
+		{
+			name:         "deadline would exceed",
+			opts:         &Option{Delay: 1 * time.Hour, Timeout: Unlimited},
+			deadline:     1 * time.Second,
+			wantReason:   ErrDeadlineWouldExceed,
+			wantAttempts: 1,
+			wantLast:     true,
+		},
+		{
+			name:         "initial delay would exceed deadline",
+			opts:         &Option{InitialDelay: 1 * time.Hour},
+			deadline:     1 * time.Second,
+			wantReason:   ErrDeadlineWouldExceed,
+			wantAttempts: 0,
+			wantLast:     false,
+		},
+		{
+			name:         "stopped while waiting",
+			opts:         &Option{Delay: 1 * time.Hour, Timeout: Unlimited},
+			stopAfter:    10 * time.Millisecond,
+			wantReason:   ErrStopped,
+			wantAttempts: 1,
+			wantLast:     true,
+		},
+		{
+			name:         "aborted on non-retryable error",
+			opts:         &Option{RetryIf: func(err error) bool { return false }},
+			wantReason:   ErrAborted,
+			wantAttempts: 1,
+			wantLast:     true,
+		},
+		{
+			name:         "stopped before first attempt",
+			opts:         &Option{StopCh: stopCh},
+			wantReason:   ErrStopped,
+			wantAttempts: 0,
+			wantLast:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+			if tt.stopAfter > 0 {
+				stopCh := make(chan struct{})
+				time.AfterFunc(tt.stopAfter, func() { close(stopCh) })
+				tt.opts.StopCh = stopCh
+			}
+			err := Do(ctx, func() error {
+				return errTest
+			}, tt.opts)
+
+			var retryErr *Error
+			if !errors.As(err, &retryErr) {
+				t.Fatalf("Do() error = %v, want *Error", err)
+			}
+			if !errors.Is(err, tt.wantReason) || retryErr.Reason() != tt.wantReason {
+				t.Errorf("Do() error reason = %v, want %v", retryErr.Reason(), tt.wantReason)
+			}
+			if errors.Is(err, errTest) != tt.wantLast {
+				t.Errorf("errors.Is(last error) = %v, want %v", !tt.wantLast, tt.wantLast)
+			}
+			if retryErr.Attempts() != tt.wantAttempts {
+				t.Errorf("Attempts() = %d, want %d", retryErr.Attempts(), tt.wantAttempts)
+			}
+			if retryErr.TotalDelay() != tt.wantTotalDelay {
+				t.Errorf("TotalDelay() = %v, want %v", retryErr.TotalDelay(), tt.wantTotalDelay)
+			}
+			if retryErr.Error() == "" {
+				t.Error("Error() is empty")
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Group runs tasks concurrently, retrying each one independently with a shared Option.
+// It is similar to golang.org/x/sync/errgroup but does not cancel the other tasks
+// when one of them fails.
+type Group struct {
+	ctx  context.Context
+	opts Option
+	wg   sync.WaitGroup
+	sem  chan struct{}
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewGroup creates a Group whose tasks are retried with opts until ctx is done.
+func NewGroup(ctx context.Context, opts *Option) *Group {
+	g := &Group{ctx: ctx}
+	if opts != nil {
+		g.opts = *opts
+	}
+	return g
+}
+
+// SetLimit limits the number of tasks running at once to n. A negative value removes
+// the limit. It must not be called while tasks are running.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// Go runs f with retry logic in a new goroutine, blocking until the limit allows it.
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+			g.wg.Done()
+		}()
+
+		if err := Do(g.ctx, f, &g.opts); err != nil {
+			g.mu.Lock()
+			g.errs = append(g.errs, err)
+			g.mu.Unlock()
+		}
+	}()
+}
+
+// Wait blocks until all tasks have returned and returns a *GroupError holding the
+// errors of the tasks that failed, or nil.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) == 0 {
+		return nil
+	}
+	return &GroupError{Errors: append([]error(nil), g.errs...)}
+}
+
+// GroupError combines the errors of the failed tasks of a Group.
+type GroupError struct {
+	Errors []error
+}
+
+func (e *GroupError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the failed tasks.
+func (e *GroupError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether the error of any failed task matches target. It makes errors.Is
+// see the task errors before Go 1.20, which does not unwrap []error.
+func (e *GroupError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of a failed task that matches target, like errors.As.
+func (e *GroupError) As(target any) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
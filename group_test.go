@@ -0,0 +1,110 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      int
+		tasks      int
+		failing    int
+		wantErrors int
+	}{
+		{
+			name:       "all tasks succeed without limit",
+			limit:      -1,
+			tasks:      5,
+			failing:    0,
+			wantErrors: 0,
+		},
+		{
+			name:       "failed tasks are combined",
+			limit:      2,
+			tasks:      5,
+			failing:    2,
+			wantErrors: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				running, maxRunning int32
+				mu                  sync.Mutex
+				attempts            = make(map[int]int)
+			)
+			g := NewGroup(context.Background(), &Option{MaxRetries: 3, Delay: 1 * time.Millisecond})
+			g.SetLimit(tt.limit)
+			for i := 0; i < tt.tasks; i++ {
+				i := i
+				g.Go(func() error {
+					n := atomic.AddInt32(&running, 1)
+					defer atomic.AddInt32(&running, -1)
+					for {
+						m := atomic.LoadInt32(&maxRunning)
+						if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+							break
+						}
+					}
+					time.Sleep(1 * time.Millisecond)
+
+					mu.Lock()
+					attempts[i]++
+					attempt := attempts[i]
+					mu.Unlock()
+					if i < tt.failing || attempt < 2 {
+						return errors.New("test-error")
+					}
+					return nil
+				})
+			}
+
+			err := g.Wait()
+			var groupErr *GroupError
+			if tt.wantErrors == 0 && err != nil {
+				t.Fatalf("Wait() error = %v, want nil", err)
+			}
+			if tt.wantErrors > 0 && (!errors.As(err, &groupErr) || len(groupErr.Errors) != tt.wantErrors) {
+				t.Fatalf("Wait() error = %v, want %d error(s)", err, tt.wantErrors)
+			}
+			if tt.limit > 0 && int(maxRunning) > tt.limit {
+				t.Errorf("max running tasks = %d, want at most %d", maxRunning, tt.limit)
+			}
+			for i := 0; i < tt.tasks; i++ {
+				want := 2
+				if i < tt.failing {
+					want = 3
+				}
+				if attempts[i] != want {
+					t.Errorf("task %d attempts = %d, want %d", i, attempts[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupError(t *testing.T) {
+	errTest := errors.New("test-error")
+	err := &GroupError{Errors: []error{
+		errTest,
+		newError(ErrMaxRetriesExceeded, errTest, 3, 0),
+	}}
+
+	// call the methods directly: errors.Is and errors.As only unwrap []error since Go 1.20
+	if !err.Is(ErrMaxRetriesExceeded) || !err.Is(errTest) {
+		t.Errorf("Is() = false, want true")
+	}
+	if err.Is(ErrTimeoutExceeded) {
+		t.Errorf("Is(%v) = true, want false", ErrTimeoutExceeded)
+	}
+	var retryErr *Error
+	if !err.As(&retryErr) || retryErr.Attempts() != 3 {
+		t.Errorf("As() = %v, want the *Error of the second task", retryErr)
+	}
+}
@@ -0,0 +1,63 @@
+// Package errcode extracts codes from driver and RPC errors without depending on
+// the packages that define them.
+package errcode
+
+import (
+	"errors"
+	"reflect"
+)
+
+// SQLState returns the SQLSTATE code of errors exposing a SQLState() string method,
+// such as the Postgres errors of pgx and lib/pq.
+func SQLState(err error) (string, bool) {
+	var sqlErr interface{ SQLState() string }
+	if !errors.As(err, &sqlErr) {
+		return "", false
+	}
+	return sqlErr.SQLState(), true
+}
+
+// MySQLNumber returns the error number of github.com/go-sql-driver/mysql errors,
+// read from their Number field.
+func MySQLNumber(err error) (uint64, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		f := v.FieldByName("Number")
+		if !f.IsValid() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return f.Uint(), true
+		}
+	}
+	return 0, false
+}
+
+// GRPCCode returns the status code of errors exposing a GRPCStatus() method whose
+// result has a Code() method, such as the errors of google.golang.org/grpc.
+func GRPCCode(err error) (uint32, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		m := reflect.ValueOf(err).MethodByName("GRPCStatus")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+		status := m.Call(nil)[0]
+		if status.Kind() == reflect.Ptr && status.IsNil() {
+			continue
+		}
+		c := status.MethodByName("Code")
+		if !c.IsValid() || c.Type().NumIn() != 0 || c.Type().NumOut() != 1 {
+			continue
+		}
+		code := c.Call(nil)[0]
+		if code.Kind() != reflect.Uint32 {
+			continue
+		}
+		return uint32(code.Uint()), true
+	}
+	return 0, false
+}
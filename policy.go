@@ -0,0 +1,145 @@
+package retry
+
+import "time"
+
+// Policy is a reusable set of retry parameters. Use the presets or NewPolicy to
+// share reviewed defaults instead of picking numbers at every call site.
+type Policy struct {
+	MaxRetries            int           // Maximum number of retry attempts
+	Delay                 time.Duration // Initial delay between retries
+	MaxDelay              time.Duration // Upper bound of the delay between retries
+	Timeout               time.Duration // Total timeout for retries
+	UseExponential        bool          // Enable exponential backoff
+	UseJitter             bool          // Add random jitter to the delay
+	UseDecorrelatedJitter bool          // Pick each delay randomly between Delay and three times the previous delay
+	RetryableCodes        []string      // Error codes that are retryable; errors with other codes are not (default: all)
+}
+
+// Option returns a new Option configured with the policy parameters.
+func (p *Policy) Option() *Option {
+	return &Option{
+		MaxRetries:            p.MaxRetries,
+		Delay:                 p.Delay,
+		MaxDelay:              p.MaxDelay,
+		Timeout:               p.Timeout,
+		UseExponential:        p.UseExponential,
+		UseJitter:             p.UseJitter,
+		UseDecorrelatedJitter: p.UseDecorrelatedJitter,
+		RetryIf:               retryableCodes(p.RetryableCodes),
+	}
+}
+
+// PolicyAggressive retries quickly and often; suited for cheap in-memory or local operations.
+func PolicyAggressive() *Policy {
+	return &Policy{
+		MaxRetries:     10,
+		Delay:          50 * time.Millisecond,
+		MaxDelay:       1 * time.Second,
+		Timeout:        5 * time.Second,
+		UseExponential: true,
+		UseJitter:      true,
+	}
+}
+
+// PolicyAPIClient retries a few times with jittered exponential backoff; suited for remote API calls.
+func PolicyAPIClient() *Policy {
+	return &Policy{
+		MaxRetries:     4,
+		Delay:          200 * time.Millisecond,
+		MaxDelay:       5 * time.Second,
+		Timeout:        30 * time.Second,
+		UseExponential: true,
+		UseJitter:      true,
+	}
+}
+
+// PolicyDatabase retries briefly; suited for transient database errors such as deadlocks.
+func PolicyDatabase() *Policy {
+	return &Policy{
+		MaxRetries:     3,
+		Delay:          100 * time.Millisecond,
+		MaxDelay:       2 * time.Second,
+		Timeout:        10 * time.Second,
+		UseExponential: true,
+		UseJitter:      true,
+	}
+}
+
+// PolicyBuilder builds a Policy with a fluent API.
+type PolicyBuilder struct {
+	policy Policy
+}
+
+// NewPolicy starts building a Policy from the default values of Option.
+func NewPolicy() *PolicyBuilder {
+	return &PolicyBuilder{}
+}
+
+// From starts the builder from a copy of p, e.g. to tweak a preset.
+func (b *PolicyBuilder) From(p *Policy) *PolicyBuilder {
+	b.policy = *p
+	b.policy.RetryableCodes = append([]string(nil), p.RetryableCodes...)
+	return b
+}
+
+// MaxRetries sets the maximum number of retry attempts.
+func (b *PolicyBuilder) MaxRetries(n int) *PolicyBuilder {
+	b.policy.MaxRetries = n
+	return b
+}
+
+// Constant uses a fixed delay between retries.
+func (b *PolicyBuilder) Constant(delay time.Duration) *PolicyBuilder {
+	b.policy.Delay = delay
+	b.policy.UseExponential = false
+	b.policy.UseDecorrelatedJitter = false
+	return b
+}
+
+// Exponential uses exponential backoff starting at delay.
+func (b *PolicyBuilder) Exponential(delay time.Duration) *PolicyBuilder {
+	b.policy.Delay = delay
+	b.policy.UseExponential = true
+	b.policy.UseDecorrelatedJitter = false
+	return b
+}
+
+// Decorrelated uses decorrelated jitter starting at delay: each delay is picked randomly
+// between delay and three times the previous one.
+func (b *PolicyBuilder) Decorrelated(delay time.Duration) *PolicyBuilder {
+	b.policy.Delay = delay
+	b.policy.UseExponential = false
+	b.policy.UseDecorrelatedJitter = true
+	return b
+}
+
+// MaxDelay sets the upper bound of the delay between retries.
+func (b *PolicyBuilder) MaxDelay(delay time.Duration) *PolicyBuilder {
+	b.policy.MaxDelay = delay
+	return b
+}
+
+// Timeout sets the total timeout for retries.
+func (b *PolicyBuilder) Timeout(timeout time.Duration) *PolicyBuilder {
+	b.policy.Timeout = timeout
+	return b
+}
+
+// Jitter adds random jitter to the delay.
+func (b *PolicyBuilder) Jitter() *PolicyBuilder {
+	b.policy.UseJitter = true
+	return b
+}
+
+// RetryableCodes restricts retries to errors carrying one of the given codes.
+func (b *PolicyBuilder) RetryableCodes(codes ...string) *PolicyBuilder {
+	b.policy.RetryableCodes = append(b.policy.RetryableCodes, codes...)
+	return b
+}
+
+// Build returns the built Policy.
+func (b *PolicyBuilder) Build() *Policy {
+	p := b.policy
+	p.RetryableCodes = append([]string(nil), p.RetryableCodes...)
+	return &p
+}
@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPolicyBuilder(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *PolicyBuilder
+		want    *Policy
+	}{
+		{
+			name:    "empty builder",
+			builder: NewPolicy(),
+			want:    &Policy{},
+		},
+		{
+			name:    "exponential with max delay",
+			builder: NewPolicy().MaxRetries(5).Exponential(100 * time.Millisecond).MaxDelay(5 * time.Second),
+			want: &Policy{
+				MaxRetries:     5,
+				Delay:          100 * time.Millisecond,
+				MaxDelay:       5 * time.Second,
+				UseExponential: true,
+			},
+		},
+		{
+			name:    "preset tweaked to constant delay",
+			builder: NewPolicy().From(PolicyAPIClient()).Constant(1 * time.Second).Timeout(3 * time.Second),
+			want: &Policy{
+				MaxRetries: 4,
+				Delay:      1 * time.Second,
+				MaxDelay:   5 * time.Second,
+				Timeout:    3 * time.Second,
+				UseJitter:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.builder.Build(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Option(t *testing.T) {
+	p := NewPolicy().MaxRetries(2).Exponential(1 * time.Second).MaxDelay(3 * time.Second).Jitter().Build()
+	want := &Option{
+		MaxRetries:     2,
+		Delay:          1 * time.Second,
+		MaxDelay:       3 * time.Second,
+		UseExponential: true,
+		UseJitter:      true,
+	}
+	if got := p.Option(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Option() = %+v, want %+v", got, want)
+	}
+}
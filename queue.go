@@ -0,0 +1,321 @@
+package retry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	mrand "math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrJobNotFound is returned by a Store when the requested job does not exist.
+var ErrJobNotFound = errors.New("retry: job not found")
+
+// Job is a named unit of work scheduled by a Queue.
+type Job struct {
+	ID        string    // Unique identifier of the job
+	Name      string    // Name of the handler that processes the job
+	Payload   []byte    // Opaque data passed to the handler
+	Attempts  int       // Number of attempts made so far
+	NextRunAt time.Time // Earliest time the job may run again
+	LastError string    // Error message of the last failed attempt
+	Dead      bool      // True when the job exhausted its attempts
+}
+
+// Store persists jobs of a Queue. Implementations backed by Redis, SQL, etc.
+// allow scheduled retries to survive process restarts.
+type Store interface {
+	// Save inserts the job or replaces the job with the same ID.
+	Save(ctx context.Context, job Job) error
+	// Get returns the job with the given ID or ErrJobNotFound.
+	Get(ctx context.Context, id string) (Job, error)
+	// Delete removes the job with the given ID.
+	Delete(ctx context.Context, id string) error
+	// Due returns up to limit live jobs whose NextRunAt is not after now, oldest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]Job, error)
+	// Dead returns all dead-lettered jobs.
+	Dead(ctx context.Context) ([]Job, error)
+}
+
+// QueueOption configures a Queue.
+type QueueOption struct {
+	MaxRetries   int                      // Maximum number of attempts before a job is dead-lettered (default: 5)
+	Delay        time.Duration            // Initial delay between attempts, doubled on every failure (default: 1 second)
+	MaxDelay     time.Duration            // Upper bound of the delay between attempts (default: 1 hour)
+	PollInterval time.Duration            // Interval used by Run to look for due jobs (default: 1 second)
+	BatchSize    int                      // Maximum number of jobs fetched per poll (default: 100)
+	UseJitter    bool                     // Add random jitter to the delay (default: false)
+	OnDeadLetter func(job Job, err error) // Callback function invoked when a job is dead-lettered
+	OnError      func(err error)          // Callback function invoked when Run fails to process due jobs, e.g. on store errors (default: log the error)
+}
+
+// fillDefault will set required options with default value if it is not set.
+func (o *QueueOption) fillDefault() {
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 5
+	}
+	if o.Delay <= 0 {
+		o.Delay = 1 * time.Second
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = 1 * time.Hour
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 1 * time.Second
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+}
+
+// Queue schedules retries of named jobs in a Store using exponential backoff.
+// Jobs that fail MaxRetries times are kept in the store as dead jobs and can be
+// inspected with DeadJobs and requeued with Requeue.
+//
+// A Queue assumes a single consumer per Store; running several consumers on
+// the same store requires a store that claims jobs atomically in Due.
+type Queue struct {
+	store    Store
+	opts     QueueOption
+	mu       sync.RWMutex
+	handlers map[string]func(ctx context.Context, payload []byte) error
+	now      func() time.Time
+}
+
+// NewQueue creates a Queue backed by store. A nil store uses an in-memory store.
+func NewQueue(store Store, opts *QueueOption) *Queue {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	var o QueueOption
+	if opts != nil {
+		o = *opts
+	}
+	o.fillDefault()
+
+	return &Queue{
+		store:    store,
+		opts:     o,
+		handlers: make(map[string]func(ctx context.Context, payload []byte) error),
+		now:      time.Now,
+	}
+}
+
+// Handle registers the handler for jobs with the given name.
+func (q *Queue) Handle(name string, h func(ctx context.Context, payload []byte) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[name] = h
+}
+
+// Enqueue stores a new job that is due immediately and returns its ID.
+func (q *Queue) Enqueue(ctx context.Context, name string, payload []byte) (string, error) {
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+	job := Job{
+		ID:        id,
+		Name:      name,
+		Payload:   payload,
+		NextRunAt: q.now(),
+	}
+	if err = q.store.Save(ctx, job); err != nil {
+		return "", fmt.Errorf("enqueue job %q: %w", name, err)
+	}
+	return id, nil
+}
+
+// Run processes due jobs every PollInterval until ctx is done and returns ctx.Err().
+// Errors, such as a store being unavailable, are reported to OnError and do not stop Run.
+func (q *Queue) Run(ctx context.Context) error {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := q.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			if q.opts.OnError != nil {
+				q.opts.OnError(err)
+			} else {
+				log.Printf("[Retry] Queue failed to process jobs: %v\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce processes the jobs that are currently due and returns how many were attempted.
+func (q *Queue) RunOnce(ctx context.Context) (int, error) {
+	jobs, err := q.store.Due(ctx, q.now(), q.opts.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("fetch due jobs: %w", err)
+	}
+	for i, job := range jobs {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err = q.process(ctx, job); err != nil {
+			return i + 1, err
+		}
+	}
+	return len(jobs), nil
+}
+
+// process runs a single attempt of the job and stores the outcome. An attempt
+// interrupted by ctx, e.g. on shutdown, is not recorded so the job runs again later.
+func (q *Queue) process(ctx context.Context, job Job) error {
+	q.mu.RLock()
+	h, ok := q.handlers[job.Name]
+	q.mu.RUnlock()
+
+	var err error
+	if ok {
+		err = h(ctx, job.Payload)
+	} else {
+		err = fmt.Errorf("no handler registered for job %q", job.Name)
+	}
+
+	if err == nil {
+		return q.store.Delete(ctx, job.ID)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	if job.Attempts >= q.opts.MaxRetries {
+		job.Dead = true
+		if err := q.store.Save(ctx, job); err != nil {
+			return err
+		}
+		if q.opts.OnDeadLetter != nil {
+			q.opts.OnDeadLetter(job, err)
+		}
+		return nil
+	}
+
+	job.NextRunAt = q.now().Add(q.backoff(job.Attempts))
+	return q.store.Save(ctx, job)
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.opts.Delay
+	for i := 1; i < attempts && delay < q.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if q.opts.UseJitter {
+		jitter := mrand.Float64()*1.0 + 0.5
+		delay = time.Duration(float64(delay) * jitter)
+	}
+	if delay > q.opts.MaxDelay {
+		delay = q.opts.MaxDelay
+	}
+	return delay
+}
+
+// DeadJobs returns the jobs that exhausted their attempts.
+func (q *Queue) DeadJobs(ctx context.Context) ([]Job, error) {
+	return q.store.Dead(ctx)
+}
+
+// Requeue resets the attempts of a dead job and makes it due immediately.
+func (q *Queue) Requeue(ctx context.Context, id string) error {
+	job, err := q.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !job.Dead {
+		return fmt.Errorf("requeue job %s: job is not dead", id)
+	}
+	job.Dead = false
+	job.Attempts = 0
+	job.NextRunAt = q.now()
+	return q.store.Save(ctx, job)
+}
+
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// MemoryStore is an in-memory Store. Jobs do not survive process restarts.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemoryStore) Save(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+func (s *MemoryStore) Due(_ context.Context, now time.Time, limit int) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []Job
+	for _, job := range s.jobs {
+		if !job.Dead && !job.NextRunAt.After(now) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].NextRunAt.Before(jobs[j].NextRunAt)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (s *MemoryStore) Dead(_ context.Context) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []Job
+	for _, job := range s.jobs {
+		if job.Dead {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs, nil
+}
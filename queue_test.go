@@ -0,0 +1,184 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		maxRetries   int
+		wantAttempts int
+		wantDead     bool
+	}{
+		{
+			name:         "success on first attempt",
+			failures:     0,
+			maxRetries:   3,
+			wantAttempts: 1,
+			wantDead:     false,
+		},
+		{
+			name:         "success on last attempt",
+			failures:     2,
+			maxRetries:   3,
+			wantAttempts: 3,
+			wantDead:     false,
+		},
+		{
+			name:         "dead-lettered after max attempts",
+			failures:     10,
+			maxRetries:   3,
+			wantAttempts: 3,
+			wantDead:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ctx      = context.Background()
+				now      = time.Now()
+				attempts = 0
+				dead     = 0
+			)
+			q := NewQueue(nil, &QueueOption{
+				MaxRetries: tt.maxRetries,
+				Delay:      1 * time.Minute,
+				OnDeadLetter: func(job Job, err error) {
+					dead++
+				},
+			})
+			q.now = func() time.Time { return now }
+			q.Handle("job", func(ctx context.Context, payload []byte) error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("test-error")
+				}
+				return nil
+			})
+
+			id, err := q.Enqueue(ctx, "job", []byte("payload"))
+			if err != nil {
+				t.Fatalf("Enqueue() error = %v", err)
+			}
+			for i := 0; i < tt.maxRetries+1; i++ {
+				if _, err = q.RunOnce(ctx); err != nil {
+					t.Fatalf("RunOnce() error = %v", err)
+				}
+				now = now.Add(1 * time.Hour)
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			deadJobs, _ := q.DeadJobs(ctx)
+			if (len(deadJobs) == 1) != tt.wantDead || (dead == 1) != tt.wantDead {
+				t.Errorf("dead jobs = %d, callbacks = %d, wantDead %v", len(deadJobs), dead, tt.wantDead)
+			}
+			if !tt.wantDead {
+				return
+			}
+
+			attempts = 0
+			if err = q.Requeue(ctx, id); err != nil {
+				t.Fatalf("Requeue() error = %v", err)
+			}
+			if n, _ := q.RunOnce(ctx); n != 1 || attempts != 1 {
+				t.Errorf("RunOnce() after requeue = %d job(s), %d attempt(s), want 1, 1", n, attempts)
+			}
+		})
+	}
+}
+
+func TestQueue_backoff(t *testing.T) {
+	q := NewQueue(nil, &QueueOption{Delay: 1 * time.Second, MaxDelay: 5 * time.Second})
+	want := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := q.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestQueue_backoffJitter(t *testing.T) {
+	q := NewQueue(nil, &QueueOption{Delay: 1 * time.Second, MaxDelay: 5 * time.Second, UseJitter: true})
+	for i := 0; i < 100; i++ {
+		if got := q.backoff(10); got > 5*time.Second {
+			t.Fatalf("backoff(10) = %v, want at most 5s", got)
+		}
+	}
+}
+
+// flakyStore fails the first calls of Due.
+type flakyStore struct {
+	*MemoryStore
+	failures int
+}
+
+func (s *flakyStore) Due(ctx context.Context, now time.Time, limit int) ([]Job, error) {
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("store-error")
+	}
+	return s.MemoryStore.Due(ctx, now, limit)
+}
+
+func TestQueue_Run(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		store       = &flakyStore{MemoryStore: NewMemoryStore(), failures: 2}
+		errs        = 0
+	)
+	defer cancel()
+	q := NewQueue(store, &QueueOption{
+		PollInterval: 1 * time.Millisecond,
+		OnError: func(err error) {
+			errs++
+		},
+	})
+	q.Handle("job", func(ctx context.Context, payload []byte) error {
+		cancel()
+		return nil
+	})
+	if _, err := q.Enqueue(ctx, "job", nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if err := q.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if errs != 2 {
+		t.Errorf("OnError calls = %d, want 2", errs)
+	}
+	if jobs, _ := store.MemoryStore.Due(context.Background(), time.Now(), 0); len(jobs) != 0 {
+		t.Errorf("due jobs = %d, want 0", len(jobs))
+	}
+}
+
+func TestQueue_shutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := NewQueue(nil, &QueueOption{MaxRetries: 1})
+	q.Handle("job", func(ctx context.Context, payload []byte) error {
+		cancel()
+		return ctx.Err()
+	})
+	id, err := q.Enqueue(ctx, "job", nil)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if _, err = q.RunOnce(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("RunOnce() error = %v, want %v", err, context.Canceled)
+	}
+	job, err := q.store.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if job.Attempts != 0 || job.Dead {
+		t.Errorf("job attempts = %d, dead = %v, want 0, false", job.Attempts, job.Dead)
+	}
+}
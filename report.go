@@ -0,0 +1,29 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// AttemptRecord describes a single attempt of a retry loop.
+type AttemptRecord struct {
+	Attempt   int           // Number of the attempt, starting at 1
+	StartedAt time.Time     // Time the attempt started
+	Duration  time.Duration // Time the attempt took
+	Delay     time.Duration // Delay waited before the attempt
+	Err       error         // Error returned by the attempt, nil on success
+}
+
+// Report is the trace of a retry loop.
+type Report struct {
+	Attempts   []AttemptRecord // Every attempt made, in order
+	TotalDelay time.Duration   // Sum of the delays waited between attempts
+}
+
+// DoWithReport is like Do but also returns the trace of every attempt, e.g. for
+// debugging flaky integrations. The report is returned even when retrying fails.
+func DoWithReport(ctx context.Context, f func() error, opts *Option) (*Report, error) {
+	report := &Report{}
+	err := do(ctx, f, opts, report)
+	return report, err
+}
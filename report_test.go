@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoWithReport(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "success on first attempt",
+			failures:     0,
+			wantAttempts: 1,
+			wantErr:      false,
+		},
+		{
+			name:         "success on last attempt",
+			failures:     2,
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "fails on all attempts",
+			failures:     3,
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			report, err := DoWithReport(context.Background(), func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("test-error")
+				}
+				return nil
+			}, &Option{
+				MaxRetries:     3,
+				Delay:          1 * time.Millisecond,
+				UseExponential: true,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DoWithReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(report.Attempts) != tt.wantAttempts {
+				t.Fatalf("report attempts = %d, want %d", len(report.Attempts), tt.wantAttempts)
+			}
+
+			var totalDelay time.Duration
+			for i, record := range report.Attempts {
+				wantDelay := time.Duration(0)
+				if i > 0 {
+					wantDelay = time.Duration(1<<(i-1)) * time.Millisecond
+				}
+				if record.Attempt != i+1 || record.Delay != wantDelay || (record.Err != nil) != (i < tt.failures) {
+					t.Errorf("record %d = %+v, want delay %v", i, record, wantDelay)
+				}
+				totalDelay += record.Delay
+			}
+			if report.TotalDelay != totalDelay {
+				t.Errorf("report total delay = %v, want %v", report.TotalDelay, totalDelay)
+			}
+		})
+	}
+}
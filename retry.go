@@ -2,57 +2,175 @@ package retry
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
-	"math/rand"
 	"time"
 )
 
+// Unlimited disables the MaxRetries or Timeout bound of an Option. Retrying then only
+// stops on success, on the other bound, or when the context is done.
+const Unlimited = -1
+
+// ErrUnacceptableResult is the attempt error reported when the retryIf predicate of DoWithData rejects a result.
+var ErrUnacceptableResult = errors.New("retry: unacceptable result")
+
+// Limiter paces attempts. It is compatible with golang.org/x/time/rate.Limiter.
+type Limiter interface {
+	// Wait blocks until an attempt is allowed or ctx is done.
+	Wait(ctx context.Context) error
+}
+
 type Option struct {
-	MaxRetries     int                                                         // Maximum number of retry attempts (default: 3)
-	Delay          time.Duration                                               // Initial delay between retries (default: 1 second)
-	Timeout        time.Duration                                               // Total timeout for retries (default: 5 seconds)
-	UseExponential bool                                                        // Enable exponential backoff (default: false)
-	UseJitter      bool                                                        // Add random jitter to the delay (default: false)
-	OnRetry        func(totalAttempt int, totalDelay time.Duration, err error) // Callback function for custom retry event handling
+	MaxRetries              int                                                         // Maximum number of retry attempts, or Unlimited (default: 3)
+	Delay                   time.Duration                                               // Initial delay between retries (default: 1 second)
+	MaxDelay                time.Duration                                               // Upper bound of the delay between retries (default: no limit)
+	Timeout                 time.Duration                                               // Total timeout for retries, or Unlimited (default: 5 seconds)
+	UseExponential          bool                                                        // Enable exponential backoff (default: false)
+	UseJitter               bool                                                        // Add random jitter to the delay (default: false)
+	OnRetry                 func(totalAttempt int, totalDelay time.Duration, err error) // Callback function for custom retry event handling
+	Limiter                 Limiter                                                     // Rate limiter every attempt waits for, including the first (default: nil)
+	RetryIf                 func(err error) bool                                        // Decide whether the error is retryable (default: all errors are retryable)
+	StopCh                  <-chan struct{}                                             // Closing it stops retrying immediately with ErrStopped (default: nil)
+	Classifier              Classifier                                                  // Classify errors when RetryIf is not set; only NonRetryable errors stop retrying (default: nil)
+	InitialDelay            time.Duration                                               // Delay before the very first attempt (default: 0)
+	DelayBeforeRetry        bool                                                        // Wait the backoff delay before every attempt, including the first (default: false)
+	ExpectedAttemptDuration time.Duration                                               // Expected duration of an attempt, compared to the context deadline (default: moving average of past attempts)
+	UseDecorrelatedJitter   bool                                                        // Pick each delay randomly between Delay and three times the previous delay (default: false)
+	PolicyForError          func(err error) *Policy                                     // Delay schedule to use after the error; MaxRetries and Timeout still come from the Option (default: nil)
+	OnProgress              func(p Progress)                                            // Callback function invoked when a delay starts and every ProgressInterval while waiting
+	ProgressInterval        time.Duration                                               // Interval of the progress events while waiting, negative to disable them (default: 1 second)
+	Name                    string                                                      // Operation name to aggregate statistics under in DefaultRegistry; empty disables them (default: "")
+	Registry                *Registry                                                   // Registry receiving the statistics of Name in addition to DefaultRegistry (default: nil)
+	JitterSource            func() float64                                              // Random numbers in [0, 1) used for jitter, e.g. seeded in tests; must be safe for concurrent use (default: math/rand)
+}
+
+// Progress describes a retry loop waiting for its next attempt.
+type Progress struct {
+	Attempts      int           // Number of attempts made so far
+	Elapsed       time.Duration // Time since the retry loop started
+	Delay         time.Duration // Delay being waited before the next attempt
+	NextAttemptAt time.Time     // Time the next attempt is scheduled at
+	Err           error         // Error of the last attempt, nil before the first attempt
 }
 
 // fillDefault will set required options with default value if it is not set.
+// MaxRetries and Timeout are bounded independently: setting only one of them keeps
+// the default of the other, unless the other is set to Unlimited. A Delay above
+// MaxDelay is lowered to MaxDelay.
 func (o *Option) fillDefault() {
-	if o.MaxRetries <= 0 {
+	if o.MaxRetries < 0 {
+		o.MaxRetries = Unlimited
+	} else if o.MaxRetries == 0 {
 		o.MaxRetries = 3
 	}
 	if o.Delay <= 0 {
 		o.Delay = 1 * time.Second
 	}
-	if o.Timeout <= 0 {
+	if o.MaxDelay > 0 && o.MaxDelay < o.Delay {
+		o.Delay = o.MaxDelay
+	}
+	if o.Timeout < 0 {
+		o.Timeout = Unlimited
+	} else if o.Timeout == 0 {
 		o.Timeout = 5 * time.Second
 	}
+	if o.ProgressInterval == 0 {
+		o.ProgressInterval = 1 * time.Second
+	}
+}
+
+// retryable reports whether err is worth retrying according to RetryIf or Classifier.
+func (o *Option) retryable(err error) bool {
+	if errors.Is(err, ErrUnacceptableResult) {
+		return true
+	}
+	if o.RetryIf != nil {
+		return o.RetryIf(err)
+	}
+	if o.Classifier != nil {
+		return o.Classifier.Classify(err) != NonRetryable
+	}
+	return true
 }
 
 // Do attempts to execute the provided function 'f' multiple times with retry logic.
 // It will retry the function execution based on the specified options.
 func Do(ctx context.Context, f func() error, opts *Option) error {
-	if opts == nil {
-		opts = &Option{}
+	return do(ctx, f, opts, nil)
+}
+
+// do implements Do, recording every attempt in report when it is not nil.
+// It works on a copy of opts so the caller's Option is never modified.
+func do(ctx context.Context, f func() error, opts *Option, report *Report) (result error) {
+	var o Option
+	if opts != nil {
+		o = *opts
 	}
-	opts.fillDefault()
+	o.fillDefault()
 
 	var (
-		attempts   = 0
-		totalDelay time.Duration
-		delay      = opts.Delay
+		start    = time.Now()
+		attempts = 0
+		backoff  = Backoff{opts: &o}
+		lastErr  error
+		duration = attemptDuration{fixed: o.ExpectedAttemptDuration}
+		timer    *time.Timer // reused by every wait
 	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	backoff.Reset()
+
+	stats := newOperationStats(&o)
+	stats.started()
+	defer func() {
+		stats.finished(attempts, result)
+	}()
+
+	// the scheduled delay is only part of the total delay of an *Error once it was waited.
+	lastDelay := backoff.Initial()
+	if lastDelay > 0 {
+		if wouldExceedDeadline(ctx, lastDelay, duration.expected()) {
+			return newError(ErrDeadlineWouldExceed, nil, attempts, backoff.TotalDelay()-lastDelay)
+		}
+		if err := wait(ctx, &o, &timer, lastDelay, Progress{Elapsed: time.Since(start)}); err != nil {
+			return newError(err, nil, attempts, backoff.TotalDelay()-lastDelay)
+		}
+	}
 
 	for {
-		attempts++
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("retry cancelled at %d attempt(s): %w", attempts, ctx.Err())
+			return newError(ctx.Err(), lastErr, attempts, backoff.TotalDelay())
+		case <-o.StopCh:
+			return newError(ErrStopped, lastErr, attempts, backoff.TotalDelay())
 		default:
 		}
 
+		if o.Limiter != nil {
+			if err := o.Limiter.Wait(ctx); err != nil {
+				return newError(err, lastErr, attempts, backoff.TotalDelay())
+			}
+		}
+
+		attempts++
+		stats.attempt()
+
+		startedAt := time.Now()
 		err := f()
+		duration.observe(time.Since(startedAt))
+		if report != nil {
+			report.Attempts = append(report.Attempts, AttemptRecord{
+				Attempt:   attempts,
+				StartedAt: startedAt,
+				Duration:  time.Since(startedAt),
+				Delay:     lastDelay,
+				Err:       err,
+			})
+			report.TotalDelay = backoff.TotalDelay()
+		}
 		if err == nil {
 			if attempts > 1 {
 				log.Printf("[Retry] Attempt succeeded after %d attempt(s)\n", attempts)
@@ -60,25 +178,116 @@ func Do(ctx context.Context, f func() error, opts *Option) error {
 			return nil
 		}
 
-		if opts.OnRetry != nil {
-			opts.OnRetry(attempts, totalDelay, err)
+		lastErr = err
+		if o.OnRetry != nil {
+			o.OnRetry(attempts, backoff.TotalDelay(), err)
 		}
 
-		if attempts >= opts.MaxRetries {
-			return fmt.Errorf("retry failed after %d attempt(s) with total delay: %fs", attempts, totalDelay.Seconds())
+		if !o.retryable(err) {
+			return newError(ErrAborted, err, attempts, backoff.TotalDelay())
 		}
-		if totalDelay >= opts.Timeout {
-			return fmt.Errorf("retry failed after reach timeout(%fs) with %d attempt(s) ", opts.Timeout.Seconds(), attempts)
+
+		var reason exhaustion
+		lastDelay, reason = backoff.next(err)
+		switch reason {
+		case maxRetriesReached:
+			return newError(ErrMaxRetriesExceeded, err, attempts, backoff.TotalDelay())
+		case timeoutReached:
+			return newError(ErrTimeoutExceeded, err, attempts, backoff.TotalDelay())
 		}
+		if wouldExceedDeadline(ctx, lastDelay, duration.expected()) {
+			return newError(ErrDeadlineWouldExceed, err, attempts, backoff.TotalDelay()-lastDelay)
+		}
+		progress := Progress{Attempts: attempts, Elapsed: time.Since(start), Err: err}
+		if err := wait(ctx, &o, &timer, lastDelay, progress); err != nil {
+			return newError(err, lastErr, attempts, backoff.TotalDelay()-lastDelay)
+		}
+	}
+}
+
+// attemptDuration estimates the duration of the next attempt.
+type attemptDuration struct {
+	fixed time.Duration // ExpectedAttemptDuration, used instead of the average when set
+	ewma  time.Duration // exponentially weighted moving average of past attempts
+	seen  bool
+}
+
+func (a *attemptDuration) observe(d time.Duration) {
+	if !a.seen {
+		a.ewma, a.seen = d, true
+		return
+	}
+	a.ewma = time.Duration(0.3*float64(d) + 0.7*float64(a.ewma))
+}
+
+func (a *attemptDuration) expected() time.Duration {
+	if a.fixed > 0 {
+		return a.fixed
+	}
+	return a.ewma
+}
+
+// wouldExceedDeadline reports whether an attempt taking expected after waiting delay
+// would end after the deadline of ctx.
+func wouldExceedDeadline(ctx context.Context, delay, expected time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Now().Add(delay+expected).After(deadline)
+}
 
-		if opts.UseJitter {
-			jitter := rand.Float64()*1.0 + 0.5
-			delay = time.Duration(float64(delay) * jitter)
+// wait waits for the delay unless ctx is done or StopCh is closed first, reporting
+// progress to OnProgress meanwhile. The timer is created on first use and reused.
+func wait(ctx context.Context, opts *Option, timer **time.Timer, delay time.Duration, progress Progress) error {
+	if *timer == nil {
+		*timer = time.NewTimer(delay)
+	} else {
+		(*timer).Reset(delay)
+	}
+
+	var (
+		tick  <-chan time.Time
+		start = time.Now().Add(-progress.Elapsed)
+	)
+	if opts.OnProgress != nil {
+		progress.Delay = delay
+		progress.NextAttemptAt = time.Now().Add(delay)
+		opts.OnProgress(progress)
+		if opts.ProgressInterval > 0 {
+			ticker := time.NewTicker(opts.ProgressInterval)
+			defer ticker.Stop()
+			tick = ticker.C
 		}
-		totalDelay += delay
-		time.Sleep(delay)
-		if opts.UseExponential {
-			delay = delay * 2
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-opts.StopCh:
+			return ErrStopped
+		case <-(*timer).C:
+			return nil
+		case <-tick:
+			progress.Elapsed = time.Since(start)
+			opts.OnProgress(progress)
 		}
 	}
 }
+
+// DoWithData is like Do for functions returning a value. When retryIf is not nil and
+// reports true for a value, the attempt is retried as if it failed with ErrUnacceptableResult.
+// The value of the last attempt is returned, even on failure.
+func DoWithData[T any](ctx context.Context, f func() (T, error), opts *Option, retryIf func(result T) bool) (T, error) {
+	var result T
+	err := Do(ctx, func() error {
+		var err error
+		result, err = f()
+		if err != nil {
+			return err
+		}
+		if retryIf != nil && retryIf(result) {
+			return ErrUnacceptableResult
+		}
+		return nil
+	}, opts)
+	return result, err
+}
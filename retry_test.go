@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"os"
 	"testing"
 	"time"
 )
@@ -179,3 +182,395 @@ func TestDo(t *testing.T) {
 		})
 	}
 }
+
+type testLimiter struct {
+	waits int
+	err   error
+}
+
+func (l *testLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	return l.err
+}
+
+func TestDo_limiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		limiter   *testLimiter
+		failures  int
+		wantWaits int
+		wantErr   bool
+	}{
+		{
+			name:      "waits before first attempt",
+			limiter:   &testLimiter{},
+			failures:  0,
+			wantWaits: 1,
+			wantErr:   false,
+		},
+		{
+			name:      "waits before every attempt",
+			limiter:   &testLimiter{},
+			failures:  2,
+			wantWaits: 3,
+			wantErr:   false,
+		},
+		{
+			name:      "fails when limiter fails",
+			limiter:   &testLimiter{err: errors.New("limiter-error")},
+			failures:  0,
+			wantWaits: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(context.Background(), func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("test-error")
+				}
+				return nil
+			}, &Option{
+				MaxRetries: 3,
+				Delay:      1 * time.Millisecond,
+				Limiter:    tt.limiter,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.limiter.waits != tt.wantWaits {
+				t.Errorf("Limiter.Wait() calls = %d, want %d", tt.limiter.waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func TestDo_retryIf(t *testing.T) {
+	errPermanent := errors.New("permanent-error")
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		if attempts == 2 {
+			return errPermanent
+		}
+		return errors.New("test-error")
+	}, &Option{
+		MaxRetries: 5,
+		Delay:      1 * time.Millisecond,
+		RetryIf: func(err error) bool {
+			return !errors.Is(err, errPermanent)
+		},
+	})
+	if !errors.Is(err, errPermanent) {
+		t.Errorf("Do() error = %v, want %v", err, errPermanent)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+}
+
+func TestDo_stopCh(t *testing.T) {
+	tests := []struct {
+		name    string
+		stop    func(cancel context.CancelFunc, stopCh chan struct{})
+		wantErr error
+	}{
+		{
+			name:    "stopped by stop channel",
+			stop:    func(cancel context.CancelFunc, stopCh chan struct{}) { close(stopCh) },
+			wantErr: ErrStopped,
+		},
+		{
+			name:    "cancelled by context",
+			stop:    func(cancel context.CancelFunc, stopCh chan struct{}) { cancel() },
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stopCh := make(chan struct{})
+
+			start := time.Now()
+			err := Do(ctx, func() error {
+				tt.stop(cancel, stopCh)
+				return errors.New("test-error")
+			}, &Option{
+				MaxRetries: 3,
+				Delay:      1 * time.Hour,
+				StopCh:     stopCh,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if time.Since(start) > 1*time.Second {
+				t.Errorf("Do() did not return immediately")
+			}
+		})
+	}
+}
+
+func TestDoWithData(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "ready on first attempt",
+			statuses: []string{"READY"},
+			want:     "READY",
+			wantErr:  false,
+		},
+		{
+			name:     "ready after pending results",
+			statuses: []string{"PENDING", "PENDING", "READY"},
+			want:     "READY",
+			wantErr:  false,
+		},
+		{
+			name:     "still pending after all attempts",
+			statuses: []string{"PENDING", "PENDING", "PENDING", "READY"},
+			want:     "PENDING",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			got, err := DoWithData(context.Background(), func() (string, error) {
+				attempts++
+				return tt.statuses[attempts-1], nil
+			}, &Option{
+				MaxRetries: 3,
+				Delay:      1 * time.Millisecond,
+				RetryIf: func(err error) bool {
+					return false // result-based retries do not depend on RetryIf
+				},
+			}, func(result string) bool {
+				return result != "READY"
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DoWithData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DoWithData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo_initialDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       *Option
+		wantDelays []time.Duration
+	}{
+		{
+			name: "retry then delay by default",
+			opts: &Option{
+				MaxRetries:     3,
+				Delay:          1 * time.Millisecond,
+				UseExponential: true,
+			},
+			wantDelays: []time.Duration{0, 1 * time.Millisecond, 2 * time.Millisecond},
+		},
+		{
+			name: "initial delay before first attempt",
+			opts: &Option{
+				MaxRetries:     3,
+				Delay:          1 * time.Millisecond,
+				UseExponential: true,
+				InitialDelay:   5 * time.Millisecond,
+			},
+			wantDelays: []time.Duration{5 * time.Millisecond, 1 * time.Millisecond, 2 * time.Millisecond},
+		},
+		{
+			name: "delay then retry",
+			opts: &Option{
+				MaxRetries:       3,
+				Delay:            1 * time.Millisecond,
+				UseExponential:   true,
+				DelayBeforeRetry: true,
+			},
+			wantDelays: []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, _ := DoWithReport(context.Background(), func() error {
+				return errors.New("test-error")
+			}, tt.opts)
+			var got []time.Duration
+			for _, record := range report.Attempts {
+				got = append(got, record.Delay)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", got, tt.wantDelays)
+			}
+		})
+	}
+}
+
+func TestDo_unlimited(t *testing.T) {
+	attempts := 0
+	err := Do(context.Background(), func() error {
+		attempts++
+		if attempts < 10 {
+			return errors.New("test-error")
+		}
+		return nil
+	}, &Option{
+		MaxRetries: Unlimited,
+		Delay:      1 * time.Millisecond,
+		Timeout:    Unlimited,
+	})
+	if err != nil {
+		t.Errorf("Do() error = %v, want nil", err)
+	}
+	if attempts != 10 {
+		t.Errorf("attempts = %d, want 10", attempts)
+	}
+}
+
+func TestDo_deadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *Option
+		wantErr error
+	}{
+		{
+			name: "gives up when the delay exceeds the deadline",
+			opts: &Option{
+				MaxRetries: 3,
+				Delay:      1 * time.Hour,
+			},
+			wantErr: ErrDeadlineWouldExceed,
+		},
+		{
+			name: "gives up when the expected attempt exceeds the deadline",
+			opts: &Option{
+				MaxRetries:              3,
+				Delay:                   1 * time.Millisecond,
+				ExpectedAttemptDuration: 1 * time.Hour,
+			},
+			wantErr: ErrDeadlineWouldExceed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			err := Do(ctx, func() error {
+				return errors.New("test-error")
+			}, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if time.Since(start) > 100*time.Millisecond {
+				t.Errorf("Do() did not return early")
+			}
+		})
+	}
+}
+
+func TestDo_onProgress(t *testing.T) {
+	var events []Progress
+	_ = Do(context.Background(), func() error {
+		return errors.New("test-error")
+	}, &Option{
+		MaxRetries:       2,
+		Delay:            50 * time.Millisecond,
+		ProgressInterval: 20 * time.Millisecond,
+		OnProgress: func(p Progress) {
+			events = append(events, p)
+		},
+	})
+
+	// one event when the delay starts, then one per interval while waiting
+	if len(events) < 2 || len(events) > 4 {
+		t.Fatalf("progress events = %d, want between 2 and 4", len(events))
+	}
+	for i, p := range events {
+		if p.Attempts != 1 || p.Delay != 50*time.Millisecond || p.Err == nil {
+			t.Errorf("event %d = %+v, want 1 attempt, 50ms delay and error", i, p)
+		}
+		if p.NextAttemptAt != events[0].NextAttemptAt {
+			t.Errorf("event %d next attempt at = %v, want %v", i, p.NextAttemptAt, events[0].NextAttemptAt)
+		}
+		if i > 0 && p.Elapsed <= events[i-1].Elapsed {
+			t.Errorf("event %d elapsed = %v, want more than %v", i, p.Elapsed, events[i-1].Elapsed)
+		}
+	}
+}
+
+func TestDo_doesNotMutateOption(t *testing.T) {
+	opts := &Option{MaxRetries: 2, Delay: 1 * time.Millisecond}
+	_ = Do(context.Background(), func() error {
+		return errors.New("test-error")
+	}, opts)
+	if opts.MaxRetries != 2 || opts.Delay != 1*time.Millisecond || opts.Timeout != 0 || opts.ProgressInterval != 0 {
+		t.Errorf("Do() changed option to %+v", *opts)
+	}
+}
+
+func BenchmarkDo(b *testing.B) {
+	ctx := context.Background()
+	opts := &Option{MaxRetries: 2}
+	f := func() error { return nil }
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Do(ctx, f, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDo_retry(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	var (
+		ctx      = context.Background()
+		opts     = &Option{MaxRetries: 2, Delay: 1 * time.Nanosecond}
+		attempts = 0
+		errTest  = errors.New("test-error")
+	)
+	f := func() error {
+		attempts++
+		if attempts%2 == 1 {
+			return errTest
+		}
+		return nil
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Do(ctx, f, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDo_fail(b *testing.B) {
+	var (
+		ctx     = context.Background()
+		opts    = &Option{MaxRetries: 2, Delay: 1 * time.Nanosecond}
+		errTest = errors.New("test-error")
+	)
+	f := func() error { return errTest }
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := Do(ctx, f, opts); err == nil {
+			b.Fatal("Do() error = nil, want error")
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Retryer runs functions with a Policy that can be swapped at runtime, e.g. to
+// tune retry behavior during incidents without redeploying.
+type Retryer struct {
+	policy   atomic.Pointer[Policy]
+	adaptive atomic.Pointer[adaptive]
+	name     atomic.Pointer[string]
+	stats    Registry
+}
+
+// NewRetryer creates a Retryer using p. A nil policy uses the Option defaults.
+func NewRetryer(p *Policy) *Retryer {
+	r := &Retryer{}
+	r.SetPolicy(p)
+	return r
+}
+
+// SetPolicy atomically replaces the policy. Retries already in progress keep the previous policy.
+func (r *Retryer) SetPolicy(p *Policy) {
+	if p == nil {
+		p = &Policy{}
+	}
+	r.policy.Store(p)
+}
+
+// Policy returns the current policy.
+func (r *Retryer) Policy() *Policy {
+	return r.policy.Load()
+}
+
+// SetName sets the operation name the retry loops of the Retryer are recorded under,
+// both in its own statistics and in DefaultRegistry. Nothing is recorded without a name.
+func (r *Retryer) SetName(name string) {
+	r.name.Store(&name)
+}
+
+// Stats returns a snapshot of the counters of the retry loops run by the Retryer by operation name.
+func (r *Retryer) Stats() map[string]OperationStats {
+	return r.stats.Snapshot()
+}
+
+// EnableAdaptive makes the Retryer track the success rate of recent attempts and
+// widen delays and reduce attempts while the failure rate is high, relaxing again
+// as the downstream recovers. It resets any previously tracked state.
+func (r *Retryer) EnableAdaptive(opts *AdaptiveOption) {
+	r.adaptive.Store(newAdaptive(opts))
+}
+
+// AdaptiveState returns the current adaptive state. Without adaptive backoff it
+// reports no samples and the unadjusted policy.
+func (r *Retryer) AdaptiveState() AdaptiveState {
+	opts := r.Policy().Option()
+	opts.fillDefault()
+	if a := r.adaptive.Load(); a != nil {
+		return a.state(opts.MaxRetries)
+	}
+	return AdaptiveState{DelayMultiplier: 1, MaxRetries: opts.MaxRetries}
+}
+
+// Do attempts to execute f with retry logic using the current policy.
+func (r *Retryer) Do(ctx context.Context, f func() error) error {
+	f, opts := r.prepare(f)
+	return Do(ctx, f, opts)
+}
+
+// prepare returns the Option for the current policy and the function to run.
+func (r *Retryer) prepare(f func() error) (func() error, *Option) {
+	opts := r.Policy().Option()
+	if name := r.name.Load(); name != nil {
+		opts.Name = *name
+		opts.Registry = &r.stats
+	}
+	if a := r.adaptive.Load(); a != nil {
+		f = a.apply(opts, f)
+	}
+	return f, opts
+}
+
+// Start runs f with retry logic in a new goroutine and returns a Handle to stop or wait for it.
+func (r *Retryer) Start(ctx context.Context, f func() error) *Handle {
+	h := &Handle{
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	f, opts := r.prepare(f)
+	opts.StopCh = h.stopCh
+
+	go func() {
+		defer close(h.done)
+		h.err = Do(ctx, f, opts)
+	}()
+	return h
+}
+
+// Handle controls a retry loop started with Retryer.Start.
+type Handle struct {
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	err      error
+}
+
+// Stop abandons pending retries; the loop returns ErrStopped without waiting for the next
+// delay. An attempt that is already running is not interrupted.
+func (h *Handle) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.stopCh)
+	})
+}
+
+// Done returns a channel that is closed when the retry loop has returned.
+func (h *Handle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the retry loop has returned and returns its error.
+func (h *Handle) Wait() error {
+	<-h.done
+	return h.err
+}
@@ -0,0 +1,76 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryer_SetPolicy(t *testing.T) {
+	r := NewRetryer(NewPolicy().MaxRetries(2).Constant(1 * time.Millisecond).Build())
+
+	attempts := 0
+	f := func() error {
+		attempts++
+		return errors.New("test-error")
+	}
+	if err := r.Do(context.Background(), f); err == nil {
+		t.Fatal("Do() error = nil, want error")
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+
+	attempts = 0
+	r.SetPolicy(NewPolicy().MaxRetries(4).Constant(1 * time.Millisecond).Build())
+	if err := r.Do(context.Background(), f); err == nil {
+		t.Fatal("Do() error = nil, want error")
+	}
+	if attempts != 4 {
+		t.Errorf("attempts after SetPolicy = %d, want 4", attempts)
+	}
+}
+
+func TestRetryer_Stats(t *testing.T) {
+	t.Cleanup(DefaultRegistry.Reset)
+	r := NewRetryer(NewPolicy().MaxRetries(2).Constant(1 * time.Millisecond).Build())
+	f := func() error { return errors.New("test-error") }
+
+	_ = r.Do(context.Background(), f)
+	if got := r.Stats(); len(got) != 0 {
+		t.Errorf("Stats() without name = %v, want empty", got)
+	}
+
+	r.SetName("retryer-stats")
+	_ = r.Do(context.Background(), f)
+	want := OperationStats{Started: 1, Attempts: 2, Exhausted: 1}
+	if got := r.Stats()["retryer-stats"]; got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if got := Stats()["retryer-stats"]; got != want {
+		t.Errorf("global Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRetryer_Start(t *testing.T) {
+	r := NewRetryer(NewPolicy().MaxRetries(10).Constant(1 * time.Hour).Build())
+
+	attempted := make(chan struct{}, 10)
+	h := r.Start(context.Background(), func() error {
+		attempted <- struct{}{}
+		return errors.New("test-error")
+	})
+	<-attempted
+	h.Stop()
+	h.Stop() // stopping twice is safe
+
+	select {
+	case <-h.Done():
+	case <-time.After(1 * time.Second):
+		t.Fatal("Stop() did not stop the retry loop")
+	}
+	if err := h.Wait(); !errors.Is(err, ErrStopped) {
+		t.Errorf("Wait() error = %v, want %v", err, ErrStopped)
+	}
+}
@@ -0,0 +1,103 @@
+// Package retrymq retries message-queue consumer handlers: immediate in-process
+// retries first, then tiered delayed retries, and finally a dead-letter callback.
+//
+// The wrapped handler returns nil when the message is done with, i.e. it was handled,
+// scheduled for a delayed retry or dead-lettered, so the consumer can ack it or
+// commit its offset. A non-nil error means the message must be redelivered.
+package retrymq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	retry "github.com/rizanw/go-retry"
+)
+
+// Handler processes a message.
+type Handler[M any] func(ctx context.Context, msg M) error
+
+// Scheduler republishes messages for delayed retries through the broker, e.g. to a
+// Kafka retry topic, with an SQS visibility timeout or a RabbitMQ delayed exchange.
+type Scheduler[M any] interface {
+	// Retries returns how many delayed retries msg already went through, e.g. from a header.
+	Retries(msg M) int
+	// Schedule republishes msg to be redelivered after delay.
+	Schedule(ctx context.Context, msg M, delay time.Duration) error
+}
+
+// Config configures the retries of a Handler.
+type Config[M any] struct {
+	Immediate  *retry.Option                                     // In-process retries of every delivery (default: retry.Option defaults)
+	Tiers      []time.Duration                                   // Delays of the delayed retries, e.g. 1 minute, 10 minutes, 1 hour
+	Scheduler  Scheduler[M]                                      // Broker hooks for delayed retries; when nil, delays are waited in process
+	DeadLetter func(ctx context.Context, msg M, err error) error // Callback function invoked after all retries failed
+}
+
+// Wrap returns a Handler that runs h with the retries described by cfg.
+func Wrap[M any](h Handler[M], cfg Config[M]) Handler[M] {
+	return func(ctx context.Context, msg M) error {
+		err := runImmediate(ctx, h, msg, cfg.Immediate)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err // the consumer is shutting down, leave the message for redelivery
+		}
+		if errors.Is(err, retry.ErrAborted) {
+			return deadLetter(ctx, msg, cfg, err) // non-retryable errors skip the delayed retries
+		}
+
+		if cfg.Scheduler != nil {
+			if n := cfg.Scheduler.Retries(msg); n < len(cfg.Tiers) {
+				if err := cfg.Scheduler.Schedule(ctx, msg, cfg.Tiers[n]); err != nil {
+					return fmt.Errorf("schedule delayed retry %d: %w", n+1, err)
+				}
+				return nil
+			}
+			return deadLetter(ctx, msg, cfg, err)
+		}
+
+		if err = retryInProcess(ctx, h, msg, cfg, err); err == nil || ctx.Err() != nil {
+			return err
+		}
+		return deadLetter(ctx, msg, cfg, err)
+	}
+}
+
+// retryInProcess waits for each tier delay and runs the immediate retries again. It
+// returns nil as soon as a retry succeeds, or the last error.
+func retryInProcess[M any](ctx context.Context, h Handler[M], msg M, cfg Config[M], err error) error {
+	for _, delay := range cfg.Tiers {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if err = runImmediate(ctx, h, msg, cfg.Immediate); err == nil || errors.Is(err, retry.ErrAborted) {
+			return err
+		}
+	}
+	return err
+}
+
+// deadLetter hands msg to the DeadLetter callback, or returns err when there is none.
+func deadLetter[M any](ctx context.Context, msg M, cfg Config[M], err error) error {
+	if cfg.DeadLetter == nil {
+		return err
+	}
+	if dlErr := cfg.DeadLetter(ctx, msg, err); dlErr != nil {
+		return fmt.Errorf("dead-letter message: %w", dlErr)
+	}
+	return nil
+}
+
+// runImmediate runs h with in-process retries.
+func runImmediate[M any](ctx context.Context, h Handler[M], msg M, opts *retry.Option) error {
+	return retry.Do(ctx, func() error {
+		return h(ctx, msg)
+	}, opts)
+}
@@ -0,0 +1,130 @@
+package retrymq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	retry "github.com/rizanw/go-retry"
+)
+
+type testMessage struct {
+	retries int
+}
+
+type testScheduler struct {
+	delays []time.Duration
+}
+
+func (s *testScheduler) Retries(msg *testMessage) int { return msg.retries }
+
+func (s *testScheduler) Schedule(ctx context.Context, msg *testMessage, delay time.Duration) error {
+	s.delays = append(s.delays, delay)
+	return nil
+}
+
+var errPermanent = errors.New("permanent-error")
+
+func TestWrap(t *testing.T) {
+	tiers := []time.Duration{1 * time.Millisecond, 2 * time.Millisecond}
+	tests := []struct {
+		name          string
+		failures      int
+		retries       int
+		scheduler     *testScheduler
+		permanent     bool
+		wantAttempts  int
+		wantScheduled []time.Duration
+		wantDead      bool
+	}{
+		{
+			name:         "handled after immediate retries",
+			failures:     1,
+			wantAttempts: 2,
+		},
+		{
+			name:         "handled after in-process delayed retry",
+			failures:     3,
+			wantAttempts: 4,
+		},
+		{
+			name:         "dead-lettered after in-process delayed retries",
+			failures:     100,
+			wantAttempts: 6,
+			wantDead:     true,
+		},
+		{
+			name:         "dead-lettered without delayed retries on non-retryable error",
+			failures:     100,
+			permanent:    true,
+			wantAttempts: 1,
+			wantDead:     true,
+		},
+		{
+			name:          "scheduled for next tier",
+			failures:      100,
+			retries:       1,
+			scheduler:     &testScheduler{},
+			wantAttempts:  2,
+			wantScheduled: []time.Duration{2 * time.Millisecond},
+		},
+		{
+			name:         "dead-lettered after scheduled tiers",
+			failures:     100,
+			retries:      2,
+			scheduler:    &testScheduler{},
+			wantAttempts: 2,
+			wantDead:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				attempts = 0
+				dead     = false
+			)
+			cfg := Config[*testMessage]{
+				Immediate: &retry.Option{
+					MaxRetries: 2,
+					Delay:      1 * time.Millisecond,
+					RetryIf: func(err error) bool {
+						return !errors.Is(err, errPermanent)
+					},
+				},
+				Tiers: tiers,
+				DeadLetter: func(ctx context.Context, msg *testMessage, err error) error {
+					dead = true
+					return nil
+				},
+			}
+			if tt.scheduler != nil {
+				cfg.Scheduler = tt.scheduler
+			}
+			h := Wrap(func(ctx context.Context, msg *testMessage) error {
+				attempts++
+				if attempts <= tt.failures && tt.permanent {
+					return errPermanent
+				}
+				if attempts <= tt.failures {
+					return errors.New("test-error")
+				}
+				return nil
+			}, cfg)
+
+			if err := h(context.Background(), &testMessage{retries: tt.retries}); err != nil {
+				t.Fatalf("handler error = %v, want nil", err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if dead != tt.wantDead {
+				t.Errorf("dead-lettered = %v, want %v", dead, tt.wantDead)
+			}
+			if tt.scheduler != nil && fmt.Sprint(tt.scheduler.delays) != fmt.Sprint(tt.wantScheduled) {
+				t.Errorf("scheduled delays = %v, want %v", tt.scheduler.delays, tt.wantScheduled)
+			}
+		})
+	}
+}
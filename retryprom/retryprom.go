@@ -0,0 +1,71 @@
+// Package retryprom exports the statistics of a retry.Registry as a Prometheus
+// Collector. It is a separate module so the retry package does not depend on the
+// Prometheus client.
+package retryprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	retry "github.com/rizanw/go-retry"
+)
+
+// metrics are the counters exported by a Collector, labelled by operation name.
+var metrics = []struct {
+	desc  *prometheus.Desc
+	value func(s retry.OperationStats) int64
+}{
+	{
+		desc:  desc("retry_operations_started_total", "Retry loops started."),
+		value: func(s retry.OperationStats) int64 { return s.Started },
+	},
+	{
+		desc:  desc("retry_attempts_total", "Attempts made, including the first ones."),
+		value: func(s retry.OperationStats) int64 { return s.Attempts },
+	},
+	{
+		desc:  desc("retry_succeeded_after_retry_total", "Retry loops that succeeded after at least one failed attempt."),
+		value: func(s retry.OperationStats) int64 { return s.SucceededAfterRetry },
+	},
+	{
+		desc:  desc("retry_exhausted_total", "Retry loops that gave up after the maximum number of attempts."),
+		value: func(s retry.OperationStats) int64 { return s.Exhausted },
+	},
+	{
+		desc:  desc("retry_budget_rejected_total", "Retry loops that gave up because of the timeout budget or the context deadline."),
+		value: func(s retry.OperationStats) int64 { return s.BudgetRejected },
+	},
+}
+
+func desc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(name, help, []string{"operation"}, nil)
+}
+
+// Collector is a prometheus.Collector reading the counters of a retry.Registry:
+//
+//	prometheus.MustRegister(retryprom.NewCollector(retry.DefaultRegistry))
+type Collector struct {
+	registry *retry.Registry
+}
+
+// NewCollector creates a Collector for r. A nil registry uses retry.DefaultRegistry.
+func NewCollector(r *retry.Registry) *Collector {
+	if r == nil {
+		r = retry.DefaultRegistry
+	}
+	return &Collector{registry: r}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range metrics {
+		ch <- m.desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for name, stats := range c.registry.Snapshot() {
+		for _, m := range metrics {
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.CounterValue, float64(m.value(stats)), name)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package retryprom
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	retry "github.com/rizanw/go-retry"
+)
+
+func TestCollector(t *testing.T) {
+	r := retry.NewRegistry()
+	_ = retry.Do(context.Background(), func() error {
+		return errors.New("test-error")
+	}, &retry.Option{Name: "charge", MaxRetries: 2, Delay: 1 * time.Millisecond, Registry: r})
+	t.Cleanup(retry.DefaultRegistry.Reset)
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(NewCollector(r)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	want := `
+# HELP retry_attempts_total Attempts made, including the first ones.
+# TYPE retry_attempts_total counter
+retry_attempts_total{operation="charge"} 2
+# HELP retry_exhausted_total Retry loops that gave up after the maximum number of attempts.
+# TYPE retry_exhausted_total counter
+retry_exhausted_total{operation="charge"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "retry_attempts_total", "retry_exhausted_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,63 @@
+// Package retrysql retries database/sql transactions on transient failures such as
+// serialization failures and deadlocks.
+package retrysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	retry "github.com/rizanw/go-retry"
+)
+
+// DefaultClassifier retries the transaction on the errors retry.PostgresClassifier and
+// retry.MySQLClassifier consider retryable, such as serialization failures, deadlocks
+// and lost connections. Every other error aborts the transaction.
+var DefaultClassifier = retry.Classifiers(
+	retry.PostgresClassifier,
+	retry.MySQLClassifier,
+	retry.ClassifierFunc(func(err error) retry.Verdict {
+		return retry.NonRetryable
+	}),
+)
+
+// DoTx begins a transaction, runs f and commits it. The whole transaction is retried
+// when it fails with an error accepted by opts.RetryIf or opts.Classifier, or by
+// DefaultClassifier when neither is set. The transaction is rolled back between attempts.
+func DoTx(ctx context.Context, db *sql.DB, opts *retry.Option, f func(tx *sql.Tx) error) error {
+	return DoTxWithOptions(ctx, db, nil, opts, f)
+}
+
+// DoTxWithOptions is like DoTx but begins the transaction with txOpts, e.g. to use
+// serializable isolation.
+func DoTxWithOptions(ctx context.Context, db *sql.DB, txOpts *sql.TxOptions, opts *retry.Option, f func(tx *sql.Tx) error) error {
+	var o retry.Option
+	if opts != nil {
+		o = *opts
+	}
+	if o.RetryIf == nil && o.Classifier == nil {
+		o.Classifier = DefaultClassifier
+	}
+
+	return retry.Do(ctx, func() error {
+		return runTx(ctx, db, txOpts, f)
+	}, &o)
+}
+
+func runTx(ctx context.Context, db *sql.DB, txOpts *sql.TxOptions, f func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, txOpts)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err = f(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
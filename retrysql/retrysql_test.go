@@ -0,0 +1,161 @@
+package retrysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	retry "github.com/rizanw/go-retry"
+)
+
+type testDriver struct {
+	begins, commits, rollbacks int
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) { return &testConn{d: d}, nil }
+
+// Connect implements driver.Connector, so tests use sql.OpenDB instead of registering a driver.
+func (d *testDriver) Connect(context.Context) (driver.Conn, error) { return d.Open("") }
+
+func (d *testDriver) Driver() driver.Driver { return d }
+
+type testConn struct {
+	d *testDriver
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *testConn) Close() error { return nil }
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	c.d.begins++
+	return &testTx{d: c.d}, nil
+}
+
+type testTx struct {
+	d *testDriver
+}
+
+func (tx *testTx) Commit() error {
+	tx.d.commits++
+	return nil
+}
+
+func (tx *testTx) Rollback() error {
+	tx.d.rollbacks++
+	return nil
+}
+
+type testPgError struct {
+	code string
+}
+
+func (e *testPgError) Error() string { return "pg error " + e.code }
+
+func (e *testPgError) SQLState() string { return e.code }
+
+type testMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *testMySQLError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+func TestDoTx(t *testing.T) {
+	tests := []struct {
+		name          string
+		classifier    retry.Classifier
+		errs          []error
+		wantErr       bool
+		wantBegins    int
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{
+			name:        "commits on first attempt",
+			wantBegins:  1,
+			wantCommits: 1,
+		},
+		{
+			name:          "retries postgres serialization failure",
+			errs:          []error{&testPgError{code: "40001"}},
+			wantBegins:    2,
+			wantCommits:   1,
+			wantRollbacks: 1,
+		},
+		{
+			name:          "retries mysql deadlock",
+			errs:          []error{fmt.Errorf("update: %w", &testMySQLError{Number: 1213, Message: "Deadlock found"})},
+			wantBegins:    2,
+			wantCommits:   1,
+			wantRollbacks: 1,
+		},
+		{
+			name:          "retries postgres connection failure",
+			errs:          []error{&testPgError{code: "08006"}},
+			wantBegins:    2,
+			wantCommits:   1,
+			wantRollbacks: 1,
+		},
+		{
+			name:          "retries mysql lost connection",
+			errs:          []error{&testMySQLError{Number: 2013, Message: "Lost connection to MySQL server during query"}},
+			wantBegins:    2,
+			wantCommits:   1,
+			wantRollbacks: 1,
+		},
+		{
+			name:          "does not retry other errors",
+			errs:          []error{&testPgError{code: "23505"}},
+			wantErr:       true,
+			wantBegins:    1,
+			wantRollbacks: 1,
+		},
+		{
+			name:          "does not retry non-sql errors",
+			errs:          []error{errors.New("test-error")},
+			wantErr:       true,
+			wantBegins:    1,
+			wantRollbacks: 1,
+		},
+		{
+			name: "uses the option classifier",
+			classifier: retry.ClassifierFunc(func(err error) retry.Verdict {
+				return retry.Retryable
+			}),
+			errs:          []error{&testPgError{code: "23505"}},
+			wantBegins:    2,
+			wantCommits:   1,
+			wantRollbacks: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &testDriver{}
+			db := sql.OpenDB(d)
+			defer db.Close()
+
+			attempt := 0
+			err := DoTx(context.Background(), db, &retry.Option{MaxRetries: 3, Delay: 1 * time.Millisecond, Classifier: tt.classifier}, func(tx *sql.Tx) error {
+				defer func() { attempt++ }()
+				if attempt < len(tt.errs) {
+					return tt.errs[attempt]
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DoTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d.begins != tt.wantBegins || d.commits != tt.wantCommits || d.rollbacks != tt.wantRollbacks {
+				t.Errorf("begins/commits/rollbacks = %d/%d/%d, want %d/%d/%d",
+					d.begins, d.commits, d.rollbacks, tt.wantBegins, tt.wantCommits, tt.wantRollbacks)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package retrytest helps unit-test retry configurations: it injects simulated
+// failures deterministically and asserts on the attempts and delay schedule.
+//
+// Schedule and AssertSchedule compute the delays of a configuration without sleeping;
+// set retry.Option.JitterSource to Rand to make jittered configurations, such as the
+// policy presets, deterministic.
+package retrytest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	retry "github.com/rizanw/go-retry"
+)
+
+// ErrInjected is the error returned by injected failures when no error is given.
+var ErrInjected = errors.New("retrytest: injected failure")
+
+// Injector decides which calls of a function fail.
+type Injector struct {
+	mu    sync.Mutex
+	fail  func(call int) error
+	calls int
+}
+
+// FailFirst fails the first n calls with err, then succeeds.
+func FailFirst(n int, err error) *Injector {
+	err = orInjected(err)
+	return &Injector{fail: func(call int) error {
+		if call <= n {
+			return err
+		}
+		return nil
+	}}
+}
+
+// FailWithProbability fails every call with probability p using a generator seeded
+// with seed, so the same seed always yields the same failures.
+func FailWithProbability(p float64, err error, seed int64) *Injector {
+	err = orInjected(err)
+	rnd := rand.New(rand.NewSource(seed))
+	return &Injector{fail: func(call int) error {
+		if rnd.Float64() < p {
+			return err
+		}
+		return nil
+	}}
+}
+
+// FailSequence returns errs in order, one per call; nil entries succeed. Calls after
+// the sequence succeed.
+func FailSequence(errs ...error) *Injector {
+	return &Injector{fail: func(call int) error {
+		if call <= len(errs) {
+			return errs[call-1]
+		}
+		return nil
+	}}
+}
+
+// Func returns a function for retry.Do that only returns the injected errors.
+func (i *Injector) Func() func() error {
+	return i.Wrap(func() error { return nil })
+}
+
+// Wrap returns a function that returns the injected error, or calls f when the call
+// is not failed.
+func (i *Injector) Wrap(f func() error) func() error {
+	return func() error {
+		i.mu.Lock()
+		i.calls++
+		err := i.fail(i.calls)
+		i.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		return f()
+	}
+}
+
+// Calls returns how many times the wrapped function was called.
+func (i *Injector) Calls() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.calls
+}
+
+// AssertCalls fails the test when the wrapped function was not called want times.
+func (i *Injector) AssertCalls(t testing.TB, want int) {
+	t.Helper()
+	if got := i.Calls(); got != want {
+		t.Errorf("retrytest: calls = %d, want %d", got, want)
+	}
+}
+
+// Event is a call of retry.Option.OnRetry.
+type Event struct {
+	Attempt    int
+	TotalDelay time.Duration
+	Err        error
+}
+
+// Recorder records the calls of retry.Option.OnRetry; use its OnRetry method as the hook.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// OnRetry records an event. It has the signature of retry.Option.OnRetry.
+func (r *Recorder) OnRetry(totalAttempt int, totalDelay time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, Event{Attempt: totalAttempt, TotalDelay: totalDelay, Err: err})
+}
+
+// Events returns the recorded events in order.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// AssertEvents fails the test when the recorded attempts and total delays differ from want.
+// Errors are compared with errors.Is when the wanted error is not nil.
+func (r *Recorder) AssertEvents(t testing.TB, want []Event) {
+	t.Helper()
+	got := r.Events()
+	if len(got) != len(want) {
+		t.Errorf("retrytest: OnRetry events = %v, want %v", got, want)
+		return
+	}
+	for i := range got {
+		if got[i].Attempt != want[i].Attempt || got[i].TotalDelay != want[i].TotalDelay ||
+			(want[i].Err != nil && !errors.Is(got[i].Err, want[i].Err)) {
+			t.Errorf("retrytest: OnRetry event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// AssertDelays fails the test when the delays waited before each attempt of report
+// differ from want. The first attempt waits 0 unless InitialDelay or DelayBeforeRetry is set.
+func AssertDelays(t testing.TB, report *retry.Report, want []time.Duration) {
+	t.Helper()
+	got := make([]time.Duration, len(report.Attempts))
+	for i, a := range report.Attempts {
+		got[i] = a.Delay
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("retrytest: delays = %v, want %v", got, want)
+	}
+}
+
+// Rand returns a retry.Option.JitterSource drawing from a generator seeded with seed,
+// so the same seed always yields the same jitter. It is safe for concurrent use.
+func Rand(seed int64) func() float64 {
+	var (
+		mu  sync.Mutex
+		rnd = rand.New(rand.NewSource(seed))
+	)
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return rnd.Float64()
+	}
+}
+
+// Schedule returns the delays Do would wait before each attempt, without sleeping, when
+// the attempts fail with errs in order and the next one succeeds. Like in a retry.Report,
+// the first delay is the one before the first attempt. The schedule ends early when
+// MaxRetries or the Timeout budget stops retrying.
+func Schedule(opts *retry.Option, errs ...error) []time.Duration {
+	b := retry.NewBackoff(opts)
+	delays := []time.Duration{b.Initial()}
+	for _, err := range errs {
+		d, ok := b.NextFor(err)
+		if !ok {
+			break
+		}
+		delays = append(delays, d)
+	}
+	return delays
+}
+
+// AssertSchedule fails the test when the Schedule of opts for errs differs from want.
+func AssertSchedule(t testing.TB, opts *retry.Option, errs []error, want []time.Duration) {
+	t.Helper()
+	if got := Schedule(opts, errs...); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("retrytest: schedule = %v, want %v", got, want)
+	}
+}
+
+func orInjected(err error) error {
+	if err == nil {
+		return ErrInjected
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package retrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	retry "github.com/rizanw/go-retry"
+)
+
+func TestInjector(t *testing.T) {
+	errA, errB := errors.New("error-a"), errors.New("error-b")
+	tests := []struct {
+		name      string
+		injector  *Injector
+		wantErrs  []error
+		wantCalls int
+	}{
+		{
+			name:      "fail first calls",
+			injector:  FailFirst(2, nil),
+			wantErrs:  []error{ErrInjected, ErrInjected, nil, nil},
+			wantCalls: 4,
+		},
+		{
+			name:      "fail with sequence",
+			injector:  FailSequence(errA, nil, errB),
+			wantErrs:  []error{errA, nil, errB, nil},
+			wantCalls: 4,
+		},
+		{
+			name:      "fail with probability 1",
+			injector:  FailWithProbability(1, errA, 1),
+			wantErrs:  []error{errA, errA, errA, errA},
+			wantCalls: 4,
+		},
+		{
+			name:      "fail with probability 0",
+			injector:  FailWithProbability(0, errA, 1),
+			wantErrs:  []error{nil, nil, nil, nil},
+			wantCalls: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.injector.Func()
+			for i, want := range tt.wantErrs {
+				if err := f(); !errors.Is(err, want) || (want == nil && err != nil) {
+					t.Errorf("call %d error = %v, want %v", i+1, err, want)
+				}
+			}
+			tt.injector.AssertCalls(t, tt.wantCalls)
+		})
+	}
+}
+
+func TestFailWithProbability_deterministic(t *testing.T) {
+	a, b := FailWithProbability(0.5, nil, 42).Func(), FailWithProbability(0.5, nil, 42).Func()
+	for i := 0; i < 100; i++ {
+		if (a() == nil) != (b() == nil) {
+			t.Fatalf("call %d differs for the same seed", i+1)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var (
+		injector = FailFirst(2, nil)
+		recorder = &Recorder{}
+	)
+	report, err := retry.DoWithReport(context.Background(), injector.Func(), &retry.Option{
+		MaxRetries:     5,
+		Delay:          1 * time.Millisecond,
+		UseExponential: true,
+		OnRetry:        recorder.OnRetry,
+	})
+	if err != nil {
+		t.Fatalf("DoWithReport() error = %v", err)
+	}
+
+	injector.AssertCalls(t, 3)
+	recorder.AssertEvents(t, []Event{
+		{Attempt: 1, TotalDelay: 0, Err: ErrInjected},
+		{Attempt: 2, TotalDelay: 1 * time.Millisecond, Err: ErrInjected},
+	})
+	AssertDelays(t, report, []time.Duration{0, 1 * time.Millisecond, 2 * time.Millisecond})
+}
+
+func TestSchedule(t *testing.T) {
+	var (
+		errThrottled = errors.New("throttled")
+		errOther     = errors.New("other")
+	)
+	tests := []struct {
+		name string
+		opts *retry.Option
+		errs []error
+		want []time.Duration
+	}{
+		{
+			name: "exponential backoff",
+			opts: &retry.Option{MaxRetries: 5, Delay: 1 * time.Second, UseExponential: true},
+			errs: []error{errOther, errOther, errOther},
+			want: []time.Duration{0, 1 * time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name: "stops after max retries",
+			opts: &retry.Option{MaxRetries: 3, Delay: 1 * time.Hour, Timeout: retry.Unlimited},
+			errs: []error{errOther, errOther, errOther, errOther},
+			want: []time.Duration{0, 1 * time.Hour, 1 * time.Hour},
+		},
+		{
+			name: "per error class",
+			opts: &retry.Option{
+				MaxRetries: 5,
+				Delay:      1 * time.Second,
+				PolicyForError: func(err error) *retry.Policy {
+					if errors.Is(err, errThrottled) {
+						return retry.NewPolicy().Constant(3 * time.Second).Build()
+					}
+					return nil
+				},
+			},
+			errs: []error{errThrottled, errOther},
+			want: []time.Duration{0, 3 * time.Second, 1 * time.Second},
+		},
+		{
+			name: "jittered preset with a fixed jitter source",
+			opts: func() *retry.Option {
+				opts := retry.PolicyAPIClient().Option()
+				opts.JitterSource = func() float64 { return 0.5 }
+				return opts
+			}(),
+			errs: []error{errOther, errOther},
+			want: []time.Duration{0, 200 * time.Millisecond, 400 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AssertSchedule(t, tt.opts, tt.errs, tt.want)
+		})
+	}
+}
+
+func TestRand(t *testing.T) {
+	opts := func() *retry.Option {
+		opts := retry.PolicyAPIClient().Option()
+		opts.JitterSource = Rand(42)
+		return opts
+	}
+	errs := []error{ErrInjected, ErrInjected, ErrInjected}
+	if a, b := Schedule(opts(), errs...), Schedule(opts(), errs...); fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("schedules for the same seed differ: %v, %v", a, b)
+	}
+}
@@ -0,0 +1,77 @@
+package retry
+
+import (
+	"context"
+	"sync"
+)
+
+// sharedCall is a retry loop whose result is broadcast to every caller of its key.
+type sharedCall struct {
+	done    chan struct{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+var shared = struct {
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}{calls: make(map[string]*sharedCall)}
+
+// DoShared is like Do but collapses concurrent calls with the same key into a single
+// retry loop whose result is returned to every caller, so N goroutines do not retry
+// the same failing call independently.
+//
+// The loop runs detached from the callers' contexts and is cancelled once every
+// caller has given up. A caller whose ctx is done returns ctx.Err() immediately.
+// The options of the caller that started the loop are used.
+func DoShared(ctx context.Context, key string, f func() error, opts *Option) error {
+	shared.mu.Lock()
+	c, ok := shared.calls[key]
+	if !ok {
+		runCtx, cancel := context.WithCancel(context.Background())
+		c = &sharedCall{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		shared.calls[key] = c
+
+		var o Option
+		if opts != nil {
+			o = *opts
+		}
+		go func() {
+			defer close(c.done)
+			c.err = Do(runCtx, f, &o)
+			cancel()
+			forgetShared(key, c)
+		}()
+	}
+	c.waiters++
+	shared.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		shared.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			if shared.calls[key] == c {
+				delete(shared.calls, key)
+			}
+		}
+		shared.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// forgetShared removes the call of key, unless it was already replaced.
+func forgetShared(key string, c *sharedCall) {
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+	if shared.calls[key] == c {
+		delete(shared.calls, key)
+	}
+}
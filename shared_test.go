@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoShared(t *testing.T) {
+	var (
+		calls    int32
+		release  = make(chan struct{})
+		started  = make(chan struct{})
+		startOne sync.Once
+		wg       sync.WaitGroup
+		errs     = make([]error, 5)
+	)
+	f := func() error {
+		atomic.AddInt32(&calls, 1)
+		startOne.Do(func() { close(started) })
+		<-release
+		return errors.New("test-error")
+	}
+	opts := &Option{MaxRetries: 2, Delay: 1 * time.Millisecond}
+
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = DoShared(context.Background(), "shared-key", f, opts)
+		}(i)
+	}
+
+	// a caller giving up does not cancel the shared loop for the others
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		cancelled <- DoShared(ctx, "shared-key", f, opts)
+	}()
+	<-started
+	waitForWaiters(t, "shared-key", len(errs)+1)
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled DoShared() error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	wg.Wait()
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, ErrMaxRetriesExceeded) {
+			t.Errorf("DoShared() #%d error = %v, want %v", i, err, ErrMaxRetriesExceeded)
+		}
+	}
+}
+
+// waitForWaiters blocks until n callers joined the shared loop of key.
+func waitForWaiters(t *testing.T, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		shared.mu.Lock()
+		c, ok := shared.calls[key]
+		joined := ok && c.waiters == n
+		shared.mu.Unlock()
+		if joined {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d caller(s) did not join the shared loop", n)
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+}
@@ -0,0 +1,163 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// OperationStats are the aggregate counters of the retry loops run under an operation name.
+type OperationStats struct {
+	Started             int64 `json:"started"`               // Retry loops started
+	Attempts            int64 `json:"attempts"`              // Attempts made, including the first ones
+	SucceededAfterRetry int64 `json:"succeeded_after_retry"` // Retry loops that succeeded after at least one failed attempt
+	Exhausted           int64 `json:"exhausted"`             // Retry loops that gave up after MaxRetries attempts
+	BudgetRejected      int64 `json:"budget_rejected"`       // Retry loops that gave up because of the Timeout budget or the context deadline
+}
+
+// Registry aggregates OperationStats by operation name. Retry loops record into
+// DefaultRegistry and into Option.Registry when Option.Name is set.
+// The zero value is an empty Registry. A Registry is safe for concurrent use.
+type Registry struct {
+	mu  sync.RWMutex
+	ops map[string]*counters
+}
+
+// DefaultRegistry is the global Registry returned by Stats.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{ops: make(map[string]*counters)}
+}
+
+// Stats returns a snapshot of the counters of DefaultRegistry by operation name.
+func Stats() map[string]OperationStats {
+	return DefaultRegistry.Snapshot()
+}
+
+// Snapshot returns a copy of the counters by operation name.
+func (r *Registry) Snapshot() map[string]OperationStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshot := make(map[string]OperationStats, len(r.ops))
+	for name, c := range r.ops {
+		snapshot[name] = c.load()
+	}
+	return snapshot
+}
+
+// Reset drops all counters.
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = make(map[string]*counters)
+}
+
+// Expvar returns an expvar.Var publishing the snapshot as JSON, e.g.
+// expvar.Publish("retry", retry.DefaultRegistry.Expvar()).
+func (r *Registry) Expvar() expvar.Var {
+	return expvar.Func(func() any {
+		return r.Snapshot()
+	})
+}
+
+// counters returns the counters of the operation, creating them if needed.
+func (r *Registry) counters(name string) *counters {
+	r.mu.RLock()
+	c, ok := r.ops[name]
+	r.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok = r.ops[name]; !ok {
+		if r.ops == nil {
+			r.ops = make(map[string]*counters)
+		}
+		c = &counters{}
+		r.ops[name] = c
+	}
+	return c
+}
+
+type counters struct {
+	started             atomic.Int64
+	attempts            atomic.Int64
+	succeededAfterRetry atomic.Int64
+	exhausted           atomic.Int64
+	budgetRejected      atomic.Int64
+}
+
+func (c *counters) load() OperationStats {
+	return OperationStats{
+		Started:             c.started.Load(),
+		Attempts:            c.attempts.Load(),
+		SucceededAfterRetry: c.succeededAfterRetry.Load(),
+		Exhausted:           c.exhausted.Load(),
+		BudgetRejected:      c.budgetRejected.Load(),
+	}
+}
+
+// operationStats records a retry loop into the counters of its operation.
+type operationStats struct {
+	global, local *counters
+}
+
+// newOperationStats returns the counters of opts.Name; nothing is recorded without a name.
+func newOperationStats(opts *Option) operationStats {
+	if opts.Name == "" {
+		return operationStats{}
+	}
+	s := operationStats{global: DefaultRegistry.counters(opts.Name)}
+	if opts.Registry != nil && opts.Registry != DefaultRegistry {
+		s.local = opts.Registry.counters(opts.Name)
+	}
+	return s
+}
+
+func (s operationStats) each(f func(c *counters)) {
+	if s.global != nil {
+		f(s.global)
+	}
+	if s.local != nil {
+		f(s.local)
+	}
+}
+
+func (s operationStats) started() {
+	s.each(func(c *counters) { c.started.Add(1) })
+}
+
+func (s operationStats) attempt() {
+	s.each(func(c *counters) { c.attempts.Add(1) })
+}
+
+// finished records the outcome of a retry loop that made attempts and returned err.
+func (s operationStats) finished(attempts int, err error) {
+	if s.global == nil {
+		return
+	}
+	if err == nil {
+		if attempts > 1 {
+			s.each(func(c *counters) { c.succeededAfterRetry.Add(1) })
+		}
+		return
+	}
+	// only the reason tells why the loop gave up; the last error may be a per-attempt timeout.
+	reason := err
+	var retryErr *Error
+	if errors.As(err, &retryErr) {
+		reason = retryErr.Reason()
+	}
+	switch {
+	case errors.Is(reason, ErrMaxRetriesExceeded):
+		s.each(func(c *counters) { c.exhausted.Add(1) })
+	case errors.Is(reason, ErrTimeoutExceeded), errors.Is(reason, ErrDeadlineWouldExceed), errors.Is(reason, context.DeadlineExceeded):
+		s.each(func(c *counters) { c.budgetRejected.Add(1) })
+	}
+}
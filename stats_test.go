@@ -0,0 +1,101 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      *Option
+		failures  int
+		want      OperationStats
+		wantLocal bool
+	}{
+		{
+			name:     "success on first attempt",
+			opts:     &Option{Name: "stats-first", Delay: 1 * time.Millisecond},
+			failures: 0,
+			want:     OperationStats{Started: 1, Attempts: 1},
+		},
+		{
+			name:     "success after retry",
+			opts:     &Option{Name: "stats-retry", Delay: 1 * time.Millisecond},
+			failures: 2,
+			want:     OperationStats{Started: 1, Attempts: 3, SucceededAfterRetry: 1},
+		},
+		{
+			name:     "exhausted",
+			opts:     &Option{Name: "stats-exhausted", MaxRetries: 2, Delay: 1 * time.Millisecond},
+			failures: 5,
+			want:     OperationStats{Started: 1, Attempts: 2, Exhausted: 1},
+		},
+		{
+			name:     "timeout budget",
+			opts:     &Option{Name: "stats-budget", MaxRetries: 5, Delay: 2 * time.Millisecond, Timeout: 1 * time.Millisecond},
+			failures: 5,
+			want:     OperationStats{Started: 1, Attempts: 2, BudgetRejected: 1},
+		},
+		{
+			name:     "context deadline",
+			opts:     &Option{Name: "stats-deadline", Limiter: &testLimiter{err: context.DeadlineExceeded}},
+			failures: 5,
+			want:     OperationStats{Started: 1, BudgetRejected: 1},
+		},
+		{
+			name:      "local registry",
+			opts:      &Option{Name: "stats-local", Delay: 1 * time.Millisecond, Registry: NewRegistry()},
+			failures:  1,
+			want:      OperationStats{Started: 1, Attempts: 2, SucceededAfterRetry: 1},
+			wantLocal: true,
+		},
+	}
+	t.Cleanup(DefaultRegistry.Reset)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			_ = Do(context.Background(), func() error {
+				attempts++
+				if attempts <= tt.failures {
+					return errors.New("test-error")
+				}
+				return nil
+			}, tt.opts)
+
+			if got := Stats()[tt.opts.Name]; got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+			if tt.wantLocal {
+				if got := tt.opts.Registry.Snapshot()[tt.opts.Name]; got != tt.want {
+					t.Errorf("Registry.Snapshot() = %+v, want %+v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStats_unnamed(t *testing.T) {
+	before := len(Stats())
+	_ = Do(context.Background(), func() error { return nil }, &Option{})
+	if got := len(Stats()); got != before {
+		t.Errorf("len(Stats()) = %d, want %d", got, before)
+	}
+}
+
+func TestRegistry_Expvar(t *testing.T) {
+	r := &Registry{}
+	r.counters("op").attempts.Add(3)
+
+	var got map[string]OperationStats
+	if err := json.Unmarshal([]byte(r.Expvar().String()), &got); err != nil {
+		t.Fatalf("unmarshal expvar: %v", err)
+	}
+	if want := map[string]OperationStats{"op": {Attempts: 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expvar() = %v, want %v", got, want)
+	}
+}